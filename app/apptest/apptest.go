@@ -0,0 +1,281 @@
+// Package apptest runs the whole API on a throwaway database for the tests
+// of the handlers and services
+package apptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"notasGo/app"
+	"notasGo/config"
+	"notasGo/database"
+	"notasGo/migrations"
+	"notasGo/models"
+	"notasGo/ratelimit"
+	"notasGo/routes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password is the password of the accounts created by SignUp
+const Password = "secret123"
+
+// verifyLink finds the token in the link of a verification email
+var verifyLink = regexp.MustCompile(`/verify\?token=([A-Za-z0-9_-]+)`)
+
+// resetLine finds the token of a password reset email, alone on its line
+var resetLine = regexp.MustCompile(`(?m)^([A-Za-z0-9_-]{32,})\r?$`)
+
+// App is the API built for a test. It uses an in-memory SQLite database of
+// its own unless DB_DRIVER and DB_DSN name another one, which is emptied
+// first, so the same tests run on every dialect.
+type App struct {
+	Container *app.Container
+	Router    *gin.Engine
+
+	t         testing.TB
+	outboxDir string
+}
+
+// User is an account created through the API, verified and logged in
+type User struct {
+	ID           uint
+	Username     string
+	Email        string
+	AccessToken  string
+	RefreshToken string
+}
+
+// New builds the API for t with the configuration of the environment, a
+// low bcrypt cost and the request limits off, then applies configure. The
+// emails are written to an outbox in a temporary directory. Everything is
+// released when t ends.
+func New(t testing.TB, configure ...func(cfg *config.Config)) *App {
+	t.Helper()
+
+	// The tests run in the directory of their package
+	_, file, _, _ := runtime.Caller(0)
+	root := filepath.Join(filepath.Dir(file), "..", "..")
+	t.Setenv("TEMPLATES_DIR", filepath.Join(root, "templates"))
+	t.Setenv("STATIC_DIR", filepath.Join(root, "static"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.Mode = config.ModeTest
+	cfg.Auth.BcryptCost = bcrypt.MinCost
+	cfg.Auth.LoginIPRate = ratelimit.Rate{}
+	cfg.Auth.LoginAccountRate = ratelimit.Rate{}
+	cfg.RateLimit.Store = config.StoreMemory
+	cfg.RateLimit.Users = ratelimit.Rate{}
+	cfg.RateLimit.Notes = ratelimit.Rate{}
+	cfg.RateLimit.Legacy = ratelimit.Rate{}
+	cfg.Mail.Driver = config.MailerOutbox
+	cfg.Mail.OutboxDir = t.TempDir()
+	for _, apply := range configure {
+		apply(cfg)
+	}
+
+	if cfg.Database.Driver == config.DriverSQLite {
+		// Each test gets its own database, dropped with its last connection
+		name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+		cfg.Database.DSN = fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
+	} else {
+		empty(t, cfg.Database)
+	}
+
+	conn, err := database.Connect(cfg.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	container, err := app.NewContainer(cfg, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { container.Close() })
+
+	return &App{
+		Container: container,
+		Router:    routes.SetupRouter(container),
+		t:         t,
+		outboxDir: cfg.Mail.OutboxDir,
+	}
+}
+
+// empty reverts every migration applied to the database of cfg, so the
+// test starts on an empty schema
+func empty(t testing.TB, cfg config.DatabaseConfig) {
+	t.Helper()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	statuses, err := migrations.Statuses(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Down(db, len(statuses)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// NewRequest returns a request to path sending body as JSON, unless it is
+// nil, authorized with the access token when it is not empty
+func (a *App) NewRequest(method string, path string, token string, body any) *http.Request {
+	a.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// Do serves req and returns the response
+func (a *App) Do(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	a.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// Request serves a request built with NewRequest and returns the response
+func (a *App) Request(method string, path string, token string, body any) *httptest.ResponseRecorder {
+	a.t.Helper()
+	return a.Do(a.NewRequest(method, path, token, body))
+}
+
+// Decode reads the JSON body of rec into v, failing the test when it does
+// not have the wanted status
+func (a *App) Decode(rec *httptest.ResponseRecorder, status int, v any) {
+	a.t.Helper()
+
+	if rec.Code != status {
+		a.t.Fatalf("estado %d, se esperaba %d: %s", rec.Code, status, rec.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		a.t.Fatalf("respuesta inválida: %v: %s", err, rec.Body.String())
+	}
+}
+
+// LastMail returns the body of the last email sent to address, waiting
+// for the ones sent in the background
+func (a *App) LastMail(address string) string {
+	a.t.Helper()
+	a.Container.Background.Wait()
+
+	entries, err := os.ReadDir(a.outboxDir)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	// The names sort by sending time
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(a.outboxDir, name))
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		header, body, _ := strings.Cut(string(data), "\r\n\r\n")
+		if strings.Contains(header, "\r\nTo: "+address+"\r\n") {
+			return body
+		}
+	}
+	a.t.Fatalf("no se envió ningún email a %s", address)
+	return ""
+}
+
+// VerificationToken returns the token of the last verification link sent
+// to address
+func (a *App) VerificationToken(address string) string {
+	a.t.Helper()
+
+	match := verifyLink.FindStringSubmatch(a.LastMail(address))
+	if match == nil {
+		a.t.Fatalf("el último email a %s no lleva un enlace de verificación", address)
+	}
+	return match[1]
+}
+
+// ResetToken returns the token of the last password reset email sent to
+// address
+func (a *App) ResetToken(address string) string {
+	a.t.Helper()
+
+	match := resetLine.FindStringSubmatch(a.LastMail(address))
+	if match == nil {
+		a.t.Fatalf("el último email a %s no lleva un token de recuperación", address)
+	}
+	return match[1]
+}
+
+// Login logs in with email and password and returns the tokens
+func (a *App) Login(email string, password string) models.LoginResponse {
+	a.t.Helper()
+
+	var login models.LoginResponse
+	rec := a.Request(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: email, Password: password})
+	a.Decode(rec, http.StatusOK, &login)
+	return login
+}
+
+// SignUp registers username with an email of its own and Password,
+// verifies the email with the link mailed to it and logs in
+func (a *App) SignUp(username string) *User {
+	a.t.Helper()
+
+	email := username + "@example.com"
+	rec := a.Request(http.MethodPost, "/api/v1/auth/register", "", models.CreateUserRequest{Username: username, Email: email, Password: Password})
+	a.Decode(rec, http.StatusCreated, nil)
+
+	rec = a.Request(http.MethodGet, "/api/v1/auth/verify?token="+a.VerificationToken(email), "", nil)
+	a.Decode(rec, http.StatusOK, nil)
+
+	login := a.Login(email, Password)
+	return &User{
+		ID:           login.User.ID,
+		Username:     username,
+		Email:        email,
+		AccessToken:  login.AccessToken,
+		RefreshToken: login.RefreshToken,
+	}
+}
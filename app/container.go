@@ -0,0 +1,137 @@
+package app
+
+import (
+	"notasGo/config"
+	"notasGo/database"
+	"notasGo/mail"
+	"notasGo/metrics"
+	"notasGo/ratelimit"
+	"notasGo/repositories"
+	"notasGo/services"
+	"notasGo/tracing"
+)
+
+// Container holds the dependencies of the application wired to a single
+// database, so several instances (for example one per test) can coexist
+type Container struct {
+	Config  *config.Config
+	Metrics *metrics.Metrics
+
+	Notes    repositories.NoteRepository
+	Users    repositories.UserRepository
+	Sessions repositories.SessionRepository
+	Trash    repositories.TrashRepository
+	Lockouts repositories.LockoutRepository
+	Tokens   repositories.UserTokenRepository
+
+	// Mailer sends the emails to the users
+	Mailer mail.Mailer
+
+	// RateLimitStore keeps the buckets of the limiters
+	RateLimitStore ratelimit.Store
+	// LoginLimiter limits the login attempts, the registrations and the
+	// requests that mail or spend tokens, per client IP
+	LoginLimiter *ratelimit.Limiter
+	// UsersLimiter, NotesLimiter and LegacyLimiter limit the requests of each
+	// user to the route groups of the same name
+	UsersLimiter  *ratelimit.Limiter
+	NotesLimiter  *ratelimit.Limiter
+	LegacyLimiter *ratelimit.Limiter
+
+	// Background runs the work the requests do not wait for, and Close
+	// waits for it
+	Background *services.Background
+
+	TokenService         *services.TokenService
+	LockoutService       *services.LockoutService
+	SessionService       *services.SessionService
+	VerificationService  *services.VerificationService
+	PasswordResetService *services.PasswordResetService
+	UserService          *services.UserService
+	NoteService          *services.NoteService
+	RevisionService      *services.RevisionService
+	TagService           *services.TagService
+	TrashService         *services.TrashService
+	HealthService        *services.HealthService
+	StatsService         *services.StatsService
+}
+
+// NewContainer builds the repositories and services of the application on
+// conn, configured by cfg, and instruments conn with the metrics and traces.
+// Only one container can be built on a connection, and it must be closed
+// with Close.
+func NewContainer(cfg *config.Config, conn *database.Connection) (*Container, error) {
+	c := &Container{
+		Config:   cfg,
+		Metrics:  metrics.New(),
+		Notes:    repositories.NewNoteRepository(conn.DB, conn.Dialect, conn.SearchEnabled),
+		Users:    repositories.NewUserRepository(conn.DB),
+		Sessions: repositories.NewSessionRepository(conn.DB),
+		Trash:    repositories.NewTrashRepository(conn.DB),
+		Lockouts: repositories.NewLockoutRepository(conn.DB),
+		Tokens:   repositories.NewUserTokenRepository(conn.DB),
+	}
+
+	switch cfg.Mail.Driver {
+	case config.MailerSMTP:
+		mailer, err := mail.NewSMTPMailer(cfg.Mail.SMTPAddr, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+		if err != nil {
+			return nil, err
+		}
+		c.Mailer = mailer
+	default:
+		mailer, err := mail.NewOutboxMailer(cfg.Mail.OutboxDir, cfg.Mail.From)
+		if err != nil {
+			return nil, err
+		}
+		c.Mailer = mailer
+	}
+
+	switch cfg.RateLimit.Store {
+	case config.StoreRedis:
+		store, err := ratelimit.OpenRedisStore(cfg.RateLimit.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		c.RateLimitStore = store
+	default:
+		c.RateLimitStore = ratelimit.NewMemoryStore()
+	}
+	c.LoginLimiter = ratelimit.NewLimiter(c.RateLimitStore, "login_ip", cfg.Auth.LoginIPRate)
+	c.UsersLimiter = ratelimit.NewLimiter(c.RateLimitStore, "users", cfg.RateLimit.Users)
+	c.NotesLimiter = ratelimit.NewLimiter(c.RateLimitStore, "notes", cfg.RateLimit.Notes)
+	c.LegacyLimiter = ratelimit.NewLimiter(c.RateLimitStore, "legacy", cfg.RateLimit.Legacy)
+
+	c.Background = services.NewBackground()
+	c.TokenService = services.NewTokenService(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL)
+	c.SessionService = services.NewSessionService(c.Sessions, cfg.Auth.RefreshTokenTTL)
+	accountLimiter := ratelimit.NewLimiter(c.RateLimitStore, "login_account", cfg.Auth.LoginAccountRate)
+	c.LockoutService = services.NewLockoutService(c.Lockouts, accountLimiter, cfg.Auth.LockoutThreshold, cfg.Auth.LockoutDuration)
+	c.VerificationService = services.NewVerificationService(c.Users, c.Tokens, c.Mailer, c.Background, cfg.Server.PublicURL, cfg.Auth.VerificationTTL)
+	c.PasswordResetService = services.NewPasswordResetService(c.Users, c.Tokens, c.Mailer, c.Background, c.SessionService, c.LockoutService, cfg.Server.PublicURL, cfg.Auth.PasswordResetTTL, cfg.Auth.BcryptCost)
+	c.UserService = services.NewUserService(c.Users, c.SessionService, c.LockoutService, c.VerificationService, cfg.Auth.BcryptCost)
+	c.NoteService = services.NewNoteService(c.Notes, c.UserService)
+	c.RevisionService = services.NewRevisionService(c.Notes, c.NoteService)
+	c.TagService = services.NewTagService(c.Notes)
+	c.TrashService = services.NewTrashService(c.Notes, c.Users, c.Trash, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	c.HealthService = services.NewHealthService(conn)
+	c.StatsService = services.NewStatsService(c.Notes, c.Users)
+
+	if err := conn.DB.Use(c.Metrics.GORMPlugin()); err != nil {
+		return nil, err
+	}
+	if err := conn.DB.Use(tracing.GORMPlugin()); err != nil {
+		return nil, err
+	}
+	c.Metrics.RegisterStats(c.StatsService)
+
+	return c, nil
+}
+
+// Close waits for the work started in the background and releases the
+// connections held by the container, other than the database one it was
+// built on
+func (c *Container) Close() error {
+	c.Background.Wait()
+	return c.RateLimitStore.Close()
+}
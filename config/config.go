@@ -0,0 +1,583 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"notasGo/ratelimit"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable with the path of the optional
+// configuration file (.yaml, .yml or .toml)
+const FileEnv = "CONFIG_FILE"
+
+// Gin modes accepted by ServerConfig.Mode
+const (
+	ModeDebug   = "debug"
+	ModeRelease = "release"
+	ModeTest    = "test"
+)
+
+// Database drivers accepted by DatabaseConfig.Driver
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Trace exporters accepted by TracingConfig.Exporter
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Rate limit stores accepted by RateLimitConfig.Store
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Mail drivers accepted by MailConfig.Driver
+const (
+	MailerSMTP   = "smtp"
+	MailerOutbox = "outbox"
+)
+
+// MetricsOff as ServerConfig.MetricsAddr disables the metrics listener
+const MetricsOff = "off"
+
+// minSecretLength is the shortest JWT secret accepted in release mode
+const minSecretLength = 32
+
+// Config is the configuration of the application
+type Config struct {
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Auth      AuthConfig
+	CORS      CORSConfig
+	Trash     TrashConfig
+	Log       LogConfig
+	Tracing   TracingConfig
+	RateLimit RateLimitConfig
+	Mail      MailConfig
+}
+
+type ServerConfig struct {
+	// Addr is the address the HTTP server listens on, such as ":8080"
+	Addr string
+	// Mode is the Gin mode: debug, release or test
+	Mode         string
+	TemplatesDir string
+	StaticDir    string
+	// PublicURL is the address the clients reach the server at, such as
+	// "https://notas.example.com", used in the links mailed to users
+	PublicURL string
+	// ShutdownTimeout is how long a shutdown waits for the requests in
+	// flight before closing their connections
+	ShutdownTimeout time.Duration
+	// MetricsAddr is the address of the listener serving /metrics, kept
+	// apart from the API so the metrics are not public. MetricsOff disables it.
+	MetricsAddr string
+	// TrustedProxies lists the IPs and CIDR ranges of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers are believed. With none the
+	// client IP is the address of the connection.
+	TrustedProxies []string
+}
+
+type DatabaseConfig struct {
+	// Driver is the database engine: sqlite, postgres or mysql
+	Driver string
+	// DSN is the file name for SQLite or the connection string of the server
+	DSN string
+	// AutoMigrate applies the pending migrations at startup. When false the
+	// server refuses to start until they are applied with "migrate up".
+	AutoMigrate bool
+}
+
+type AuthConfig struct {
+	// JWTSecret signs the access tokens. When empty outside release mode a
+	// random key is used and tokens do not survive a restart.
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	BcryptCost      int
+	// LoginIPRate and LoginAccountRate limit the login attempts made from
+	// an IP and against an email. A zero rate disables the limit.
+	LoginIPRate      ratelimit.Rate
+	LoginAccountRate ratelimit.Rate
+	// LockoutThreshold is the number of failed logins in a row that lock an
+	// account for LockoutDuration. Failures older than LockoutDuration are
+	// forgotten.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// VerificationTTL is how long the link mailed to verify the email of a
+	// new account works
+	VerificationTTL time.Duration
+	// PasswordResetTTL is how long the token mailed to reset a forgotten
+	// password works
+	PasswordResetTTL time.Duration
+}
+
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API from a
+	// browser, "*" allows any. Empty disables CORS.
+	AllowedOrigins []string
+}
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+type LogConfig struct {
+	// Level is the lowest level logged: debug, info, warn or error
+	Level slog.Level
+	// Format is "json" or "text"
+	Format string
+}
+
+type TracingConfig struct {
+	// Exporter sends the spans to an OTLP collector over HTTP, writes them to
+	// stderr (stdout) or drops them (none)
+	Exporter string
+	// OTLPEndpoint is the URL of the collector, such as
+	// "http://localhost:4318". When empty the OTEL_EXPORTER_OTLP_* variables
+	// of the exporter apply.
+	OTLPEndpoint string
+	ServiceName  string
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1.
+	// Requests carrying a traceparent follow the decision of the caller.
+	SampleRatio float64
+}
+
+type RateLimitConfig struct {
+	// Store keeps the buckets in the memory of each instance (memory) or in
+	// Redis (redis), shared by all of them
+	Store    string
+	RedisURL string
+	// Users, Notes and Legacy limit the requests of each user to the route
+	// groups of the same name. A zero rate disables the limit.
+	Users  ratelimit.Rate
+	Notes  ratelimit.Rate
+	Legacy ratelimit.Rate
+}
+
+type MailConfig struct {
+	// Driver sends the emails through an SMTP server (smtp) or writes them
+	// to OutboxDir (outbox)
+	Driver string
+	// From is the sender of the emails, such as "NotasGo <no-reply@example.com>"
+	From      string
+	OutboxDir string
+	// SMTPAddr is the host:port of the SMTP server. SMTPUsername, when set,
+	// authenticates with SMTPPassword.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// setting describes a configuration value: its key in the file, the
+// environment variable that overrides it and its default
+type setting struct {
+	key   string
+	env   string
+	value string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"server.addr", "SERVER_ADDR", ":8080", stringValue(func(c *Config) *string { return &c.Server.Addr })},
+	{"server.mode", "SERVER_MODE", ModeDebug, stringValue(func(c *Config) *string { return &c.Server.Mode })},
+	{"server.templates_dir", "TEMPLATES_DIR", "templates", stringValue(func(c *Config) *string { return &c.Server.TemplatesDir })},
+	{"server.static_dir", "STATIC_DIR", "static", stringValue(func(c *Config) *string { return &c.Server.StaticDir })},
+	{"server.public_url", "PUBLIC_URL", "http://localhost:8080", stringValue(func(c *Config) *string { return &c.Server.PublicURL })},
+	{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "15s", durationValue(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"server.metrics_addr", "METRICS_ADDR", ":9090", stringValue(func(c *Config) *string { return &c.Server.MetricsAddr })},
+	{"server.trusted_proxies", "TRUSTED_PROXIES", "", listValue(func(c *Config) *[]string { return &c.Server.TrustedProxies })},
+	{"database.driver", "DB_DRIVER", DriverSQLite, stringValue(func(c *Config) *string { return &c.Database.Driver })},
+	{"database.dsn", "DB_DSN", "notas.db", stringValue(func(c *Config) *string { return &c.Database.DSN })},
+	{"database.auto_migrate", "DB_AUTO_MIGRATE", "true", boolValue(func(c *Config) *bool { return &c.Database.AutoMigrate })},
+	{"auth.jwt_secret", "JWT_SECRET", "", stringValue(func(c *Config) *string { return &c.Auth.JWTSecret })},
+	{"auth.access_token_ttl", "ACCESS_TOKEN_TTL", "15m", durationValue(func(c *Config) *time.Duration { return &c.Auth.AccessTokenTTL })},
+	{"auth.refresh_token_ttl", "REFRESH_TOKEN_TTL", "168h", durationValue(func(c *Config) *time.Duration { return &c.Auth.RefreshTokenTTL })},
+	{"auth.bcrypt_cost", "BCRYPT_COST", strconv.Itoa(bcrypt.DefaultCost), intValue(func(c *Config) *int { return &c.Auth.BcryptCost })},
+	{"auth.login_ip_rate", "AUTH_LOGIN_IP_RATE", "20/m", rateValue(func(c *Config) *ratelimit.Rate { return &c.Auth.LoginIPRate })},
+	{"auth.login_account_rate", "AUTH_LOGIN_ACCOUNT_RATE", "5/m", rateValue(func(c *Config) *ratelimit.Rate { return &c.Auth.LoginAccountRate })},
+	{"auth.lockout_threshold", "AUTH_LOCKOUT_THRESHOLD", "10", intValue(func(c *Config) *int { return &c.Auth.LockoutThreshold })},
+	{"auth.lockout_duration", "AUTH_LOCKOUT_DURATION", "15m", durationValue(func(c *Config) *time.Duration { return &c.Auth.LockoutDuration })},
+	{"auth.verification_ttl", "AUTH_VERIFICATION_TTL", "48h", durationValue(func(c *Config) *time.Duration { return &c.Auth.VerificationTTL })},
+	{"auth.password_reset_ttl", "AUTH_PASSWORD_RESET_TTL", "1h", durationValue(func(c *Config) *time.Duration { return &c.Auth.PasswordResetTTL })},
+	{"cors.allowed_origins", "CORS_ALLOWED_ORIGINS", "", listValue(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"trash.retention", "TRASH_RETENTION", "720h", durationValue(func(c *Config) *time.Duration { return &c.Trash.Retention })},
+	{"trash.purge_interval", "TRASH_PURGE_INTERVAL", "1h", durationValue(func(c *Config) *time.Duration { return &c.Trash.PurgeInterval })},
+	{"log.level", "LOG_LEVEL", "info", levelValue(func(c *Config) *slog.Level { return &c.Log.Level })},
+	{"log.format", "LOG_FORMAT", "json", stringValue(func(c *Config) *string { return &c.Log.Format })},
+	{"ratelimit.store", "RATE_LIMIT_STORE", StoreMemory, stringValue(func(c *Config) *string { return &c.RateLimit.Store })},
+	{"ratelimit.redis_url", "RATE_LIMIT_REDIS_URL", "redis://localhost:6379/0", stringValue(func(c *Config) *string { return &c.RateLimit.RedisURL })},
+	{"ratelimit.users", "RATE_LIMIT_USERS", "60/m", rateValue(func(c *Config) *ratelimit.Rate { return &c.RateLimit.Users })},
+	{"ratelimit.notes", "RATE_LIMIT_NOTES", "120/m", rateValue(func(c *Config) *ratelimit.Rate { return &c.RateLimit.Notes })},
+	{"ratelimit.legacy", "RATE_LIMIT_LEGACY", "60/m", rateValue(func(c *Config) *ratelimit.Rate { return &c.RateLimit.Legacy })},
+	{"mail.driver", "MAIL_DRIVER", MailerOutbox, stringValue(func(c *Config) *string { return &c.Mail.Driver })},
+	{"mail.from", "MAIL_FROM", "NotasGo <no-reply@localhost>", stringValue(func(c *Config) *string { return &c.Mail.From })},
+	{"mail.outbox_dir", "MAIL_OUTBOX_DIR", "outbox", stringValue(func(c *Config) *string { return &c.Mail.OutboxDir })},
+	{"mail.smtp_addr", "MAIL_SMTP_ADDR", "localhost:587", stringValue(func(c *Config) *string { return &c.Mail.SMTPAddr })},
+	{"mail.smtp_username", "MAIL_SMTP_USERNAME", "", stringValue(func(c *Config) *string { return &c.Mail.SMTPUsername })},
+	{"mail.smtp_password", "MAIL_SMTP_PASSWORD", "", stringValue(func(c *Config) *string { return &c.Mail.SMTPPassword })},
+	{"tracing.exporter", "TRACING_EXPORTER", ExporterNone, stringValue(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT", "", stringValue(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"tracing.service_name", "TRACING_SERVICE_NAME", "notasgo", stringValue(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "1", floatValue(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+}
+
+// Load builds the configuration from the defaults, the file named by
+// CONFIG_FILE if any, and the environment, each overriding the previous one.
+// Every invalid value is reported in the returned error.
+func Load() (*Config, error) {
+	fileValues, err := readFile(os.Getenv(FileEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	var errs []error
+	for _, s := range settings {
+		value := s.value
+		if fileValue, ok := fileValues[s.key]; ok {
+			value = fileValue
+			delete(fileValues, s.key)
+		}
+		if envValue := os.Getenv(s.env); envValue != "" {
+			value = envValue
+		}
+
+		if err := s.apply(cfg, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", s.env, s.key, err))
+			// Keep the default so Validate reports only the other problems
+			s.apply(cfg, s.value)
+		}
+	}
+
+	unknown := make([]string, 0, len(fileValues))
+	for key := range fileValues {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: clave desconocida %q", os.Getenv(FileEnv), key))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the values are usable, reporting every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(env string, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", env, fmt.Sprintf(format, args...)))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("SERVER_ADDR", "dirección inválida %q, usa host:puerto o :puerto", c.Server.Addr)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		invalid("SERVER_ADDR", "puerto inválido %q", port)
+	}
+
+	switch c.Server.Mode {
+	case ModeDebug, ModeRelease, ModeTest:
+	default:
+		invalid("SERVER_MODE", "modo %q no soportado, usa debug, release o test", c.Server.Mode)
+	}
+
+	if !isDir(c.Server.TemplatesDir) {
+		invalid("TEMPLATES_DIR", "el directorio %q no existe", c.Server.TemplatesDir)
+	}
+	if !isDir(c.Server.StaticDir) {
+		invalid("STATIC_DIR", "el directorio %q no existe", c.Server.StaticDir)
+	}
+	if u, err := url.Parse(c.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalid("PUBLIC_URL", "URL inválida %q, usa esquema://host[:puerto][/ruta]", c.Server.PublicURL)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("SHUTDOWN_TIMEOUT", "debe ser mayor que cero")
+	}
+	if c.Server.MetricsAddr != MetricsOff {
+		if _, port, err := net.SplitHostPort(c.Server.MetricsAddr); err != nil {
+			invalid("METRICS_ADDR", "dirección inválida %q, usa host:puerto, :puerto u off", c.Server.MetricsAddr)
+		} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			invalid("METRICS_ADDR", "puerto inválido %q", port)
+		} else if c.Server.MetricsAddr == c.Server.Addr {
+			invalid("METRICS_ADDR", "debe ser distinta de SERVER_ADDR")
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			invalid("TRUSTED_PROXIES", "proxy inválido %q, usa una IP o un rango CIDR", proxy)
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverSQLite, DriverPostgres, DriverMySQL:
+	default:
+		invalid("DB_DRIVER", "driver %q no soportado, usa sqlite, postgres o mysql", c.Database.Driver)
+	}
+	if c.Database.DSN == "" {
+		invalid("DB_DSN", "no puede estar vacío")
+	}
+
+	if c.Server.Mode == ModeRelease && len(c.Auth.JWTSecret) < minSecretLength {
+		invalid("JWT_SECRET", "en modo release debe tener al menos %d caracteres", minSecretLength)
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		invalid("ACCESS_TOKEN_TTL", "debe ser mayor que cero")
+	}
+	if c.Auth.RefreshTokenTTL <= 0 {
+		invalid("REFRESH_TOKEN_TTL", "debe ser mayor que cero")
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		invalid("BCRYPT_COST", "debe estar entre %d y %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if c.Auth.LockoutThreshold <= 0 {
+		invalid("AUTH_LOCKOUT_THRESHOLD", "debe ser mayor que cero")
+	}
+	if c.Auth.LockoutDuration <= 0 {
+		invalid("AUTH_LOCKOUT_DURATION", "debe ser mayor que cero")
+	}
+	if c.Auth.VerificationTTL <= 0 {
+		invalid("AUTH_VERIFICATION_TTL", "debe ser mayor que cero")
+	}
+	if c.Auth.PasswordResetTTL <= 0 {
+		invalid("AUTH_PASSWORD_RESET_TTL", "debe ser mayor que cero")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			invalid("CORS_ALLOWED_ORIGINS", "origen inválido %q, usa esquema://host[:puerto] o *", origin)
+		}
+	}
+
+	if c.Trash.Retention <= 0 {
+		invalid("TRASH_RETENTION", "debe ser mayor que cero")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		invalid("TRASH_PURGE_INTERVAL", "debe ser mayor que cero")
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		invalid("LOG_FORMAT", "formato %q no soportado, usa json o text", c.Log.Format)
+	}
+
+	switch c.RateLimit.Store {
+	case StoreMemory:
+	case StoreRedis:
+		u, err := url.Parse(c.RateLimit.RedisURL)
+		if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			invalid("RATE_LIMIT_REDIS_URL", "URL inválida %q, usa redis://host:puerto/db", c.RateLimit.RedisURL)
+		}
+	default:
+		invalid("RATE_LIMIT_STORE", "almacén %q no soportado, usa memory o redis", c.RateLimit.Store)
+	}
+
+	switch c.Mail.Driver {
+	case MailerSMTP:
+		if _, _, err := net.SplitHostPort(c.Mail.SMTPAddr); err != nil {
+			invalid("MAIL_SMTP_ADDR", "dirección inválida %q, usa host:puerto", c.Mail.SMTPAddr)
+		}
+	case MailerOutbox:
+		if c.Mail.OutboxDir == "" {
+			invalid("MAIL_OUTBOX_DIR", "no puede estar vacío")
+		}
+	default:
+		invalid("MAIL_DRIVER", "driver %q no soportado, usa smtp u outbox", c.Mail.Driver)
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		invalid("MAIL_FROM", "remitente inválido %q, usa nombre <email> o email", c.Mail.From)
+	}
+
+	switch c.Tracing.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		invalid("TRACING_EXPORTER", "exportador %q no soportado, usa none, stdout u otlp", c.Tracing.Exporter)
+	}
+	if c.Tracing.OTLPEndpoint != "" {
+		u, err := url.Parse(c.Tracing.OTLPEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("TRACING_OTLP_ENDPOINT", "URL inválida %q, usa http://host:puerto", c.Tracing.OTLPEndpoint)
+		}
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("TRACING_SERVICE_NAME", "no puede estar vacío")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("TRACING_SAMPLE_RATIO", "debe estar entre 0 y 1")
+	}
+
+	return errors.Join(errs...)
+}
+
+// readFile reads the configuration file at path, if any, into a map from
+// "section.key" to the value written as text
+func readFile(path string) (map[string]string, error) {
+	values := map[string]string{}
+	if path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer el archivo de configuración: %w", err)
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: formato no soportado, usa .yaml, .yml o .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	flatten("", raw, values)
+	return values, nil
+}
+
+// flatten stores the leaves of a decoded file under their dotted keys.
+// Lists are joined with commas, like in the environment.
+func flatten(prefix string, node map[string]interface{}, values map[string]string) {
+	for key, value := range node {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func stringValue(field func(*Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = strings.TrimSpace(value)
+		return nil
+	}
+}
+
+func intValue(field func(*Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("número entero inválido %q", value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func floatValue(field func(*Config) *float64) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("número inválido %q", value)
+		}
+		*field(cfg) = f
+		return nil
+	}
+}
+
+func boolValue(field func(*Config) *bool) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("valor booleano inválido %q, usa true o false", value)
+		}
+		*field(cfg) = b
+		return nil
+	}
+}
+
+func durationValue(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("duración inválida %q, usa valores como \"90s\", \"15m\" o \"720h\"", value)
+		}
+		*field(cfg) = d
+		return nil
+	}
+}
+
+func levelValue(field func(*Config) *slog.Level) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		if err := field(cfg).UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+			return fmt.Errorf("nivel inválido %q, usa debug, info, warn o error", value)
+		}
+		return nil
+	}
+}
+
+func rateValue(field func(*Config) *ratelimit.Rate) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		rate, err := ratelimit.ParseRate(value)
+		if err != nil {
+			return err
+		}
+		*field(cfg) = rate
+		return nil
+	}
+}
+
+func listValue(field func(*Config) *[]string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*field(cfg) = items
+		return nil
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"notasGo/middleware"
+	"notasGo/models"
+	"notasGo/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireCaller returns the authenticated user of the request, reporting a
+// missing token when the route was not protected by the auth middleware
+func requireCaller(c *gin.Context) (*models.User, bool) {
+	caller, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.Error(services.ErrTokenRequired)
+		return nil, false
+	}
+	return caller, true
+}
+
+// parseID converts a path parameter into a numeric ID
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
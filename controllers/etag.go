@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"notasGo/models"
+	"notasGo/services"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// noteETag returns the entity tag of a note, derived from its version counter
+func noteETag(note *models.Note) string {
+	return fmt.Sprintf("%q", strconv.Itoa(note.Version))
+}
+
+// ifMatchVersions reads the If-Match header and returns the note versions it
+// lists, any of which satisfies the precondition. It returns nil when the
+// header is missing or "*". If-Match uses the strong comparison, so weak tags
+// never match. When none of the listed tags is a note ETag the precondition
+// can never hold, so a version mismatch error is attached and ok is false.
+func ifMatchVersions(c *gin.Context) ([]int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		c.Error(services.ErrVersionMismatch)
+		return nil, false
+	}
+	return versions, true
+}
+
+// formVersions reads the version a dashboard form was rendered with, which
+// plays the role of If-Match for the HTML forms
+func formVersions(c *gin.Context) ([]int, error) {
+	version, err := strconv.Atoi(c.PostForm("version"))
+	if err != nil {
+		return nil, err
+	}
+	return []int{version}, nil
+}
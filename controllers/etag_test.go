@@ -0,0 +1,120 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"notasGo/app/apptest"
+	"notasGo/models"
+	"testing"
+)
+
+func TestNoteWritesRequireIfMatch(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Nota")
+
+	writes := []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodPut, "/api/v1/notes/%d", models.UpdateNoteRequest{Title: "Cambiada"}},
+		{http.MethodPatch, "/api/v1/notes/%d", models.UpdateNoteRequest{Title: "Cambiada"}},
+		{http.MethodDelete, "/api/v1/notes/%d", nil},
+		{http.MethodPost, "/api/v1/notes/%d/revisions/1/restore", nil},
+		{http.MethodPut, "/notes/%d", models.UpdateNoteRequest{Title: "Cambiada"}},
+		{http.MethodPatch, "/notes/%d", models.UpdateNoteRequest{Title: "Cambiada"}},
+		{http.MethodDelete, "/notes/%d", nil},
+	}
+	for _, w := range writes {
+		path := fmt.Sprintf(w.path, note.ID)
+		expectError(t, a, a.Request(w.method, path, user.AccessToken, w.body), http.StatusPreconditionRequired, "if_match_required")
+	}
+
+	// Nothing changed
+	rec := a.Request(http.MethodGet, fmt.Sprintf("/api/v1/notes/%d", note.ID), user.AccessToken, nil)
+	var resp struct {
+		Data models.NoteResponse `json:"data"`
+	}
+	a.Decode(rec, http.StatusOK, &resp)
+	if resp.Data.Version != 1 || resp.Data.Title != "Nota" {
+		t.Errorf("la nota cambió sin If-Match: versión %d, título %q", resp.Data.Version, resp.Data.Title)
+	}
+	if got := rec.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("ETag %s, se esperaba \"1\"", got)
+	}
+}
+
+func TestIfMatchComparesStrongTags(t *testing.T) {
+	for name, prefix := range map[string]string{"v1": "/api/v1", "legacy": ""} {
+		t.Run(name, func(t *testing.T) {
+			a := apptest.New(t)
+			user := a.SignUp("user")
+			note := createNote(t, a, user.AccessToken, "Nota")
+			path := fmt.Sprintf("%s/notes/%d", prefix, note.ID)
+
+			patch := func(ifMatch string) *httptest.ResponseRecorder {
+				req := a.NewRequest(http.MethodPatch, path, user.AccessToken, map[string]any{"title": "Cambiada " + ifMatch})
+				req.Header.Set("If-Match", ifMatch)
+				return a.Do(req)
+			}
+
+			// Weak tags, other versions and anything but an entity tag fail
+			for _, ifMatch := range []string{`"2"`, `W/"1"`, `1`, `"abc"`, `"0"`, `W/"1", "3"`} {
+				expectError(t, a, patch(ifMatch), http.StatusPreconditionFailed, "version_mismatch")
+			}
+
+			// Any listed tag can match, and * matches any version
+			versions := []struct {
+				ifMatch string
+				etag    string
+			}{
+				{`"1"`, `"2"`},
+				{`"7", "2"`, `"3"`},
+				{`W/"3", "3"`, `"4"`},
+				{`*`, `"5"`},
+			}
+			for _, v := range versions {
+				rec := patch(v.ifMatch)
+				a.Decode(rec, http.StatusOK, nil)
+				if got := rec.Header().Get("ETag"); got != v.etag {
+					t.Errorf("If-Match %s: ETag %s, se esperaba %s", v.ifMatch, got, v.etag)
+				}
+			}
+
+			// A stale tag no longer deletes the note, the current one does
+			req := a.NewRequest(http.MethodDelete, path, user.AccessToken, nil)
+			req.Header.Set("If-Match", `"4"`)
+			expectError(t, a, a.Do(req), http.StatusPreconditionFailed, "version_mismatch")
+			req = a.NewRequest(http.MethodDelete, path, user.AccessToken, nil)
+			req.Header.Set("If-Match", `"4", "5"`)
+			a.Decode(a.Do(req), http.StatusOK, nil)
+		})
+	}
+}
+
+func TestRevisionRestoreChecksIfMatch(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Original")
+	path := fmt.Sprintf("/api/v1/notes/%d", note.ID)
+
+	req := a.NewRequest(http.MethodPut, path, user.AccessToken, models.UpdateNoteRequest{Title: "Cambiada", Content: "otro"})
+	req.Header.Set("If-Match", `"1"`)
+	a.Decode(a.Do(req), http.StatusOK, nil)
+
+	req = a.NewRequest(http.MethodPost, path+"/revisions/1/restore", user.AccessToken, nil)
+	req.Header.Set("If-Match", `"1"`)
+	expectError(t, a, a.Do(req), http.StatusPreconditionFailed, "version_mismatch")
+
+	req = a.NewRequest(http.MethodPost, path+"/revisions/1/restore", user.AccessToken, nil)
+	req.Header.Set("If-Match", `"2"`)
+	var resp struct {
+		Data models.NoteResponse `json:"data"`
+	}
+	a.Decode(a.Do(req), http.StatusOK, &resp)
+	if resp.Data.Title != "Original" || resp.Data.Version != 3 {
+		t.Errorf("restaurada con título %q y versión %d", resp.Data.Title, resp.Data.Version)
+	}
+}
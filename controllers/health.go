@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"notasGo/logging"
+	"notasGo/models"
+	"notasGo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Values of the status fields of the health responses
+const (
+	statusOK          = "ok"
+	statusReady       = "ready"
+	statusUnavailable = "unavailable"
+	statusPending     = "pending"
+	statusError       = "error"
+)
+
+type HealthController struct {
+	healthService *services.HealthService
+}
+
+func NewHealthController(healthService *services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Healthz godoc
+// @Summary Comprobación de vida
+// @Description Responde 200 mientras el proceso atiende peticiones, sin consultar la base de datos
+// @Tags salud
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /healthz [get]
+func (ctrl *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: statusOK})
+}
+
+// Readyz godoc
+// @Summary Comprobación de disponibilidad
+// @Description Comprueba que la base de datos responde y no tiene migraciones pendientes. Responde 503 si no está lista para recibir tráfico.
+// @Tags salud
+// @Produce json
+// @Success 200 {object} models.ReadinessResponse
+// @Failure 503 {object} models.ReadinessResponse
+// @Router /readyz [get]
+func (ctrl *HealthController) Readyz(c *gin.Context) {
+	readiness := ctrl.healthService.CheckReadiness(c.Request.Context())
+
+	response := models.ReadinessResponse{
+		Status:   statusReady,
+		Database: statusOK,
+		Migrations: models.MigrationsCheck{
+			Status:  statusOK,
+			Applied: readiness.AppliedMigrations,
+			Pending: readiness.PendingMigrations,
+		},
+	}
+
+	switch {
+	case readiness.DatabaseErr != nil:
+		logging.FromContext(c.Request.Context()).Error("Readyz: la base de datos no responde", slog.Any("error", readiness.DatabaseErr))
+		response.Database = statusUnavailable
+		response.Migrations.Status = statusUnavailable
+	case readiness.MigrationsErr != nil:
+		logging.FromContext(c.Request.Context()).Error("Readyz: no se pudo leer el estado de las migraciones", slog.Any("error", readiness.MigrationsErr))
+		response.Migrations.Status = statusError
+	case readiness.PendingMigrations > 0:
+		response.Migrations.Status = statusPending
+	}
+
+	status := http.StatusOK
+	if !readiness.Ready() {
+		response.Status = statusUnavailable
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
@@ -1,25 +1,47 @@
 package controllers
 
 import (
+	"log/slog"
 	"net/http"
-	"notasGo/database"
-	"notasGo/models"
+	"notasGo/i18n"
+	"notasGo/logging"
+	"notasGo/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Dashboard(c *gin.Context) {
-	var notes []models.Note
-	if err := database.DB.Find(&notes).Error; err != nil {
+type HomeController struct {
+	noteService *services.NoteService
+}
+
+func NewHomeController(noteService *services.NoteService) *HomeController {
+	return &HomeController{
+		noteService: noteService,
+	}
+}
+
+func (ctrl *HomeController) Dashboard(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	locale := i18n.Locale(c)
+
+	notes, err := ctrl.noteService.GetVisibleNotes(c.Request.Context(), caller)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Error al cargar el dashboard", slog.Any("error", err))
 		c.HTML(http.StatusInternalServerError, "base.html", gin.H{
-			"Title": "NotasGo",
-			"Error": err.Error(),
+			"Locale": locale,
+			"Title":  i18n.Translate(locale, "dashboard.title", nil),
+			"Error":  i18n.Translate(locale, "dashboard.load_error", nil),
 		})
 		return
 	}
 
 	c.HTML(http.StatusOK, "base.html", gin.H{
-		"Title": "NotasGo",
-		"Notes": notes,
+		"Locale": locale,
+		"Title":  i18n.Translate(locale, "dashboard.title", nil),
+		"Notes":  notes,
 	})
 }
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"notasGo/i18n"
+	"notasGo/logging"
+	"notasGo/middleware"
 	"notasGo/models"
+	"notasGo/policy"
 	"notasGo/services"
 	"notasGo/utils"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,24 +19,46 @@ type NoteController struct {
 	noteService *services.NoteService
 }
 
-func NewNoteController() *NoteController {
+func NewNoteController(noteService *services.NoteService) *NoteController {
 	return &NoteController{
-		noteService: services.NewNoteService(),
+		noteService: noteService,
 	}
 }
 
 // GetNotes godoc
 // @Summary Obtiene todas las notas
-// @Description Devuelve una lista de todas las notas con información del usuario
+// @Description Devuelve una página de las notas del usuario autenticado (todas si es administrador) con información del usuario
 // @Tags notas
 // @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Tamaño de página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor devuelto en next_cursor"
+// @Param page query int false "Número de página (alternativa al cursor)"
+// @Param sort query string false "Campo de orden" Enums(created_at, updated_at, title) default(created_at)
+// @Param order query string false "Dirección del orden" Enums(asc, desc) default(desc)
+// @Param user_id query int false "Filtrar por propietario"
+// @Param tag query []string false "Filtrar por etiquetas (se puede repetir)" collectionFormat(multi)
+// @Param tag_mode query string false "any: alguna de las etiquetas, all: todas" Enums(any, all) default(any)
 // @Success 200 {object} models.NotesListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes [get]
 func (ctrl *NoteController) GetNotes(c *gin.Context) {
-	notes, total, err := ctrl.noteService.GetAllNotes()
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	var query models.NoteListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(services.ErrInvalidQuery.Wrap(err))
+		return
+	}
+
+	notes, total, page, err := ctrl.noteService.GetAllNotes(c.Request.Context(), caller, &query)
 	if err != nil {
-		utils.InternalServerError(c, "Error al obtener notas", err)
+		c.Error(err)
 		return
 	}
 
@@ -54,16 +81,95 @@ func (ctrl *NoteController) GetNotes(c *gin.Context) {
 			Content:   note.Content,
 			UserID:    note.UserID,
 			User:      userResponse,
+			Tags:      tagNames(note.Tags),
+			Version:   note.Version,
 			CreatedAt: note.CreatedAt,
 			UpdatedAt: note.UpdatedAt,
 		})
 	}
 
 	response := models.NotesListResponse{
+		Success:    true,
+		Message:    i18n.T(c, "note.listed"),
+		Notes:      noteResponses,
+		Total:      total,
+		Limit:      page.Limit,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchNotes godoc
+// @Summary Busca notas por texto
+// @Description Búsqueda de texto completo sobre título y contenido de las notas visibles por el usuario, ordenada por relevancia y con fragmentos resaltados
+// @Tags notas
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Texto a buscar"
+// @Param limit query int false "Número máximo de resultados (máximo 100)" default(20)
+// @Success 200 {object} models.NoteSearchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/v1/notes/search [get]
+func (ctrl *NoteController) SearchNotes(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	var query models.NoteSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(services.ErrInvalidQuery.Wrap(err))
+		return
+	}
+
+	hits, err := ctrl.noteService.SearchNotes(c.Request.Context(), &query, caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results := make([]models.NoteSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		note := hit.Note
+		userResponse := models.UserResponse{
+			ID:        note.User.ID,
+			Username:  note.User.Username,
+			Email:     note.User.Email,
+			Role:      note.User.Role,
+			Status:    note.User.Status,
+			CreatedAt: note.User.CreatedAt,
+			UpdatedAt: note.User.UpdatedAt,
+		}
+
+		results = append(results, models.NoteSearchResult{
+			Note: models.NoteResponse{
+				ID:        note.ID,
+				Title:     note.Title,
+				Content:   note.Content,
+				UserID:    note.UserID,
+				User:      userResponse,
+				Tags:      tagNames(note.Tags),
+				Version:   note.Version,
+				CreatedAt: note.CreatedAt,
+				UpdatedAt: note.UpdatedAt,
+			},
+			TitleSnippet: hit.TitleSnippet,
+			Snippet:      hit.Snippet,
+			Rank:         hit.Rank,
+		})
+	}
+
+	response := models.NoteSearchResponse{
 		Success: true,
-		Message: "Notas obtenidas exitosamente",
-		Notes:   noteResponses,
-		Total:   total,
+		Message: i18n.T(c, "note.searched"),
+		Query:   query.Q,
+		Results: results,
+		Total:   len(results),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -74,21 +180,30 @@ func (ctrl *NoteController) GetNotes(c *gin.Context) {
 // @Description Devuelve una nota específica con información del usuario
 // @Tags notas
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID de la nota"
 // @Success 200 {object} models.APIResponse{data=models.NoteResponse}
+// @Header 200 {string} ETag "Versión de la nota"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes/{id} [get]
 func (ctrl *NoteController) GetNoteByID(c *gin.Context) {
-	id := c.Param("id")
-	
-	note, err := ctrl.noteService.GetNoteByID(id)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		if err.Error() == "nota no encontrada" {
-			utils.NotFoundError(c, "Nota no encontrada")
-			return
-		}
-		utils.InternalServerError(c, "Error al obtener nota", err)
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	note, err := ctrl.noteService.GetNoteByID(c.Request.Context(), id, caller)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -108,38 +223,44 @@ func (ctrl *NoteController) GetNoteByID(c *gin.Context) {
 		Content:   note.Content,
 		UserID:    note.UserID,
 		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Nota obtenida exitosamente", noteResponse)
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusOK, "note.retrieved", noteResponse)
 }
 
 // CreateNote godoc
 // @Summary Crea una nueva nota
-// @Description Crea una nueva nota asociada a un usuario
+// @Description Crea una nueva nota cuyo propietario es el usuario autenticado
 // @Tags notas
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param note body models.CreateNoteRequest true "Datos de la nota"
 // @Success 201 {object} models.APIResponse{data=models.NoteResponse}
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes [post]
 func (ctrl *NoteController) CreateNote(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	var req models.CreateNoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	note, err := ctrl.noteService.CreateNote(&req)
+	note, err := ctrl.noteService.CreateNote(c.Request.Context(), &req, caller)
 	if err != nil {
-		if err.Error() == "usuario no encontrado" {
-			utils.BadRequestError(c, "Usuario no encontrado", nil)
-			return
-		}
-		utils.InternalServerError(c, "Error al crear nota", err)
+		c.Error(err)
 		return
 	}
 
@@ -159,11 +280,14 @@ func (ctrl *NoteController) CreateNote(c *gin.Context) {
 		Content:   note.Content,
 		UserID:    note.UserID,
 		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Nota creada exitosamente", noteResponse)
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusCreated, "note.created", noteResponse)
 }
 
 // UpdateNote godoc
@@ -172,33 +296,45 @@ func (ctrl *NoteController) CreateNote(c *gin.Context) {
 // @Tags notas
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID de la nota"
+// @Param If-Match header string true "ETag obtenido al leer la nota"
 // @Param note body models.UpdateNoteRequest true "Datos de la nota"
 // @Success 200 {object} models.APIResponse{data=models.NoteResponse}
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes/{id} [put]
 func (ctrl *NoteController) UpdateNote(c *gin.Context) {
-	id := c.Param("id")
-	
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
 	var req models.UpdateNoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	note, err := ctrl.noteService.UpdateNote(id, &req)
+	expectedVersions, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	note, err := ctrl.noteService.UpdateNote(c.Request.Context(), id, &req, caller, expectedVersions)
 	if err != nil {
-		if err.Error() == "nota no encontrada" {
-			utils.NotFoundError(c, "Nota no encontrada")
-			return
-		}
-		if err.Error() == "usuario no encontrado" {
-			utils.BadRequestError(c, "Usuario no encontrado", nil)
-			return
-		}
-		utils.InternalServerError(c, "Error al actualizar nota", err)
+		c.Error(err)
 		return
 	}
 
@@ -218,11 +354,14 @@ func (ctrl *NoteController) UpdateNote(c *gin.Context) {
 		Content:   note.Content,
 		UserID:    note.UserID,
 		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Nota actualizada exitosamente", noteResponse)
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusOK, "note.updated", noteResponse)
 }
 
 // PatchNote godoc
@@ -231,33 +370,45 @@ func (ctrl *NoteController) UpdateNote(c *gin.Context) {
 // @Tags notas
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID de la nota"
+// @Param If-Match header string true "ETag obtenido al leer la nota"
 // @Param updates body map[string]interface{} true "Campos a actualizar"
 // @Success 200 {object} models.APIResponse{data=models.NoteResponse}
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes/{id} [patch]
 func (ctrl *NoteController) PatchNote(c *gin.Context) {
-	id := c.Param("id")
-	
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	note, err := ctrl.noteService.PatchNote(id, updates)
+	expectedVersions, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	note, err := ctrl.noteService.PatchNote(c.Request.Context(), id, updates, caller, expectedVersions)
 	if err != nil {
-		if err.Error() == "nota no encontrada" {
-			utils.NotFoundError(c, "Nota no encontrada")
-			return
-		}
-		if err.Error() == "usuario no encontrado" {
-			utils.BadRequestError(c, "Usuario no encontrado", nil)
-			return
-		}
-		utils.InternalServerError(c, "Error al actualizar nota", err)
+		c.Error(err)
 		return
 	}
 
@@ -277,59 +428,162 @@ func (ctrl *NoteController) PatchNote(c *gin.Context) {
 		Content:   note.Content,
 		UserID:    note.UserID,
 		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Nota actualizada exitosamente", noteResponse)
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusOK, "note.updated", noteResponse)
 }
 
 // DeleteNote godoc
 // @Summary Elimina una nota
-// @Description Elimina una nota por su ID
+// @Description Mueve una nota a la papelera. Se puede restaurar hasta que la papelera se vacíe
 // @Tags notas
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID de la nota"
+// @Param If-Match header string true "ETag obtenido al leer la nota"
 // @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /notes/{id} [delete]
 func (ctrl *NoteController) DeleteNote(c *gin.Context) {
-	id := c.Param("id")
-	
-	err := ctrl.noteService.DeleteNote(id)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		if err.Error() == "nota no encontrada" {
-			utils.NotFoundError(c, "Nota no encontrada")
-			return
-		}
-		utils.InternalServerError(c, "Error al eliminar nota", err)
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	expectedVersions, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	err = ctrl.noteService.DeleteNote(c.Request.Context(), id, caller, expectedVersions)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Nota eliminada exitosamente", nil)
+	utils.SuccessResponse(c, http.StatusOK, "note.trashed", nil)
+}
+
+// RestoreNote godoc
+// @Summary Restaura una nota de la papelera
+// @Description Saca de la papelera una nota eliminada, con sus etiquetas y su historial de revisiones
+// @Tags papelera
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID de la nota"
+// @Success 200 {object} models.APIResponse{data=models.NoteResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notes/{id}/restore [post]
+func (ctrl *NoteController) RestoreNote(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	note, err := ctrl.noteService.RestoreNote(c.Request.Context(), id, caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userResponse := models.UserResponse{
+		ID:        note.User.ID,
+		Username:  note.User.Username,
+		Email:     note.User.Email,
+		Role:      note.User.Role,
+		Status:    note.User.Status,
+		CreatedAt: note.User.CreatedAt,
+		UpdatedAt: note.User.UpdatedAt,
+	}
+
+	noteResponse := models.NoteResponse{
+		ID:        note.ID,
+		Title:     note.Title,
+		Content:   note.Content,
+		UserID:    note.UserID,
+		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusOK, "note.restored", noteResponse)
 }
 
 // GetNotesByUser godoc
 // @Summary Obtiene todas las notas de un usuario
-// @Description Devuelve todas las notas que pertenecen a un usuario específico
+// @Description Devuelve todas las notas que pertenecen a un usuario específico (solo el propio usuario o un administrador)
 // @Tags notas
 // @Produce json
+// @Security BearerAuth
 // @Param user_id path int true "ID del usuario"
+// @Param limit query int false "Tamaño de página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor devuelto en next_cursor"
+// @Param page query int false "Número de página (alternativa al cursor)"
+// @Param sort query string false "Campo de orden" Enums(created_at, updated_at, title) default(created_at)
+// @Param order query string false "Dirección del orden" Enums(asc, desc) default(desc)
+// @Param tag query []string false "Filtrar por etiquetas (se puede repetir)" collectionFormat(multi)
+// @Param tag_mode query string false "any: alguna de las etiquetas, all: todas" Enums(any, all) default(any)
 // @Success 200 {object} models.UserNotesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/user/{user_id}/notes [get]
 func (ctrl *NoteController) GetNotesByUser(c *gin.Context) {
-	userID := c.Param("user_id")
-	
-	user, notes, total, err := ctrl.noteService.GetNotesByUser(userID)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := parseID(c.Param("user_id"))
 	if err != nil {
-		if err.Error() == "usuario no encontrado" {
-			utils.NotFoundError(c, "Usuario no encontrado")
-			return
-		}
-		utils.InternalServerError(c, "Error al obtener notas del usuario", err)
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	if !policy.CanViewUserNotes(caller, targetID) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.view_user_notes"))
+		return
+	}
+
+	var query models.NoteListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(services.ErrInvalidQuery.Wrap(err))
+		return
+	}
+
+	user, notes, total, page, err := ctrl.noteService.GetNotesByUser(c.Request.Context(), targetID, &query)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -351,17 +605,22 @@ func (ctrl *NoteController) GetNotesByUser(c *gin.Context) {
 			Content:   note.Content,
 			UserID:    note.UserID,
 			User:      userResponse,
+			Tags:      tagNames(note.Tags),
+			Version:   note.Version,
 			CreatedAt: note.CreatedAt,
 			UpdatedAt: note.UpdatedAt,
 		})
 	}
 
 	response := models.UserNotesResponse{
-		Success: true,
-		Message: "Notas del usuario obtenidas exitosamente",
-		User:    userResponse,
-		Notes:   noteResponses,
-		Total:   total,
+		Success:    true,
+		Message:    i18n.T(c, "note.user_listed"),
+		User:       userResponse,
+		Notes:      noteResponses,
+		Total:      total,
+		Limit:      page.Limit,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -369,26 +628,22 @@ func (ctrl *NoteController) GetNotesByUser(c *gin.Context) {
 
 // Legacy form handlers (keep for backward compatibility with HTML forms)
 func (ctrl *NoteController) CreateNoteForm(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	title := c.PostForm("title")
 	content := c.PostForm("content")
-	userIDStr := c.PostForm("user_id")
-
-	var userID uint = 1 // Default user
-	if userIDStr != "" {
-		if id, err := strconv.ParseUint(userIDStr, 10, 32); err == nil {
-			userID = uint(id)
-		}
-	}
 
 	req := models.CreateNoteRequest{
 		Title:   title,
 		Content: content,
-		UserID:  userID,
 	}
 
-	_, err := ctrl.noteService.CreateNote(&req)
+	_, err := ctrl.noteService.CreateNote(c.Request.Context(), &req, caller)
 	if err != nil {
-		c.HTML(http.StatusBadRequest, "index.html", gin.H{"Error": err.Error()})
+		renderFormError(c, err)
 		return
 	}
 
@@ -396,7 +651,21 @@ func (ctrl *NoteController) CreateNoteForm(c *gin.Context) {
 }
 
 func (ctrl *NoteController) UpdateNoteForm(c *gin.Context) {
-	id := c.PostForm("id")
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.PostForm("id"))
+	if err != nil {
+		renderFormError(c, services.ErrInvalidID.Wrap(err))
+		return
+	}
+	expectedVersions, err := formVersions(c)
+	if err != nil {
+		renderFormError(c, services.ErrInvalidRequest.Wrap(err))
+		return
+	}
 	title := c.PostForm("title")
 	content := c.PostForm("content")
 
@@ -405,9 +674,9 @@ func (ctrl *NoteController) UpdateNoteForm(c *gin.Context) {
 		Content: content,
 	}
 
-	_, err := ctrl.noteService.UpdateNote(id, &req)
+	_, err = ctrl.noteService.UpdateNote(c.Request.Context(), id, &req, caller, expectedVersions)
 	if err != nil {
-		c.HTML(http.StatusNotFound, "index.html", gin.H{"Error": err.Error()})
+		renderFormError(c, err)
 		return
 	}
 
@@ -415,13 +684,58 @@ func (ctrl *NoteController) UpdateNoteForm(c *gin.Context) {
 }
 
 func (ctrl *NoteController) DeleteNoteForm(c *gin.Context) {
-	id := c.PostForm("id")
-	
-	err := ctrl.noteService.DeleteNote(id)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.PostForm("id"))
+	if err != nil {
+		renderFormError(c, services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	expectedVersions, err := formVersions(c)
+	if err != nil {
+		renderFormError(c, services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	err = ctrl.noteService.DeleteNote(c.Request.Context(), id, caller, expectedVersions)
 	if err != nil {
-		c.HTML(http.StatusNotFound, "index.html", gin.H{"Error": err.Error()})
+		renderFormError(c, err)
 		return
 	}
 
 	c.Redirect(http.StatusSeeOther, "/")
-}
\ No newline at end of file
+}
+
+// renderFormError shows err on the dashboard in the locale of the request,
+// with the status and message the JSON API would give it. Other errors are
+// logged and shown as a generic internal error, so their details never
+// reach the page.
+func renderFormError(c *gin.Context, err error) {
+	status, messageID := http.StatusInternalServerError, "error.internal_error"
+	var domainErr *services.Error
+	if errors.As(err, &domainErr) {
+		status, messageID = middleware.ErrorStatus(domainErr), domainErr.Message()
+	} else {
+		logging.FromContext(c.Request.Context()).Error("Error interno", slog.Any("error", err))
+	}
+
+	locale := i18n.Locale(c)
+	c.HTML(status, "base.html", gin.H{
+		"Locale": locale,
+		"Title":  i18n.Translate(locale, "dashboard.title", nil),
+		"Error":  i18n.Translate(locale, messageID, nil),
+	})
+}
+
+// tagNames converts the tags of a note to the names returned by the API
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
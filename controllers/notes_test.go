@@ -0,0 +1,225 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"notasGo/app/apptest"
+	"notasGo/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// createNote creates a note titled title through the API
+func createNote(t *testing.T, a *apptest.App, token string, title string) models.NoteResponse {
+	t.Helper()
+
+	var resp struct {
+		Data models.NoteResponse `json:"data"`
+	}
+	rec := a.Request(http.MethodPost, "/api/v1/notes", token, models.CreateNoteRequest{Title: title, Content: "Contenido de " + title})
+	a.Decode(rec, http.StatusCreated, &resp)
+	return resp.Data
+}
+
+func TestNotesOfOtherUsersAreNotFound(t *testing.T) {
+	a := apptest.New(t)
+	owner := a.SignUp("owner")
+	other := a.SignUp("other")
+	note := createNote(t, a, owner.AccessToken, "Privada")
+	path := fmt.Sprintf("/api/v1/notes/%d", note.ID)
+
+	requests := []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, path, nil},
+		{http.MethodPut, path, models.UpdateNoteRequest{Title: "Robada", Content: "Robada"}},
+		{http.MethodPatch, path, models.UpdateNoteRequest{Title: "Robada"}},
+		{http.MethodDelete, path, nil},
+		{http.MethodGet, path + "/revisions", nil},
+		{http.MethodGet, path + "/revisions/1", nil},
+		{http.MethodPost, path + "/revisions/1/restore", nil},
+		{http.MethodGet, fmt.Sprintf("/notes/%d", note.ID), nil},
+	}
+	for _, r := range requests {
+		req := a.NewRequest(r.method, r.path, other.AccessToken, r.body)
+		req.Header.Set("If-Match", `"1"`)
+
+		var resp models.ErrorResponse
+		a.Decode(a.Do(req), http.StatusNotFound, &resp)
+		if resp.Code != "note_not_found" {
+			t.Errorf("%s %s: código %q, se esperaba note_not_found", r.method, r.path, resp.Code)
+		}
+	}
+
+	// The note is left as it was for its owner
+	var resp struct {
+		Data models.NoteResponse `json:"data"`
+	}
+	a.Decode(a.Request(http.MethodGet, path, owner.AccessToken, nil), http.StatusOK, &resp)
+	if resp.Data.Title != "Privada" || resp.Data.Version != 1 {
+		t.Errorf("la nota cambió: título %q, versión %d", resp.Data.Title, resp.Data.Version)
+	}
+
+	// Only the owner can take it out of the trash
+	req := a.NewRequest(http.MethodDelete, path, owner.AccessToken, nil)
+	req.Header.Set("If-Match", `"1"`)
+	a.Decode(a.Do(req), http.StatusOK, nil)
+	a.Decode(a.Request(http.MethodPost, path+"/restore", other.AccessToken, nil), http.StatusNotFound, nil)
+	a.Decode(a.Request(http.MethodPost, path+"/restore", owner.AccessToken, nil), http.StatusOK, nil)
+}
+
+func TestNoteListsOnlyShowOwnNotes(t *testing.T) {
+	a := apptest.New(t)
+	owner := a.SignUp("owner")
+	other := a.SignUp("other")
+	createNote(t, a, owner.AccessToken, "Privada")
+
+	var list models.NotesListResponse
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes", other.AccessToken, nil), http.StatusOK, &list)
+	if list.Total != 0 || len(list.Notes) != 0 {
+		t.Errorf("otro usuario ve %d notas, se esperaba ninguna", len(list.Notes))
+	}
+
+	path := fmt.Sprintf("/api/v1/user/%d/notes", owner.ID)
+	a.Decode(a.Request(http.MethodGet, path, other.AccessToken, nil), http.StatusForbidden, nil)
+}
+
+func TestMalformedNoteIDsAreRejected(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Nota")
+
+	// Anything but an unsigned integer is refused before reaching the database
+	ids := []string{
+		"abc",
+		"-1",
+		"1.5",
+		url.PathEscape(fmt.Sprintf("%d AND 1=1", note.ID)),
+		url.PathEscape(fmt.Sprintf("%d OR 1=1", note.ID)),
+	}
+	for _, id := range ids {
+		var resp models.ErrorResponse
+		a.Decode(a.Request(http.MethodGet, "/api/v1/notes/"+id, user.AccessToken, nil), http.StatusBadRequest, &resp)
+		if resp.Code != "invalid_id" {
+			t.Errorf("id %q: código %q, se esperaba invalid_id", id, resp.Code)
+		}
+	}
+
+	path := fmt.Sprintf("/api/v1/notes/%d/revisions/abc", note.ID)
+	a.Decode(a.Request(http.MethodGet, path, user.AccessToken, nil), http.StatusBadRequest, nil)
+}
+
+func TestNoteCursorPagination(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+
+	// Notes created in a row share timestamps, the cursor breaks the ties
+	created := map[int]bool{}
+	for i := range 7 {
+		note := createNote(t, a, user.AccessToken, fmt.Sprintf("Nota %d", i))
+		created[note.ID] = true
+	}
+
+	for _, order := range []string{"desc", "asc"} {
+		seen := map[int]bool{}
+		var previous int
+		cursor := ""
+		pages := 0
+		for {
+			query := url.Values{"limit": {"3"}, "order": {order}}
+			if cursor != "" {
+				query.Set("cursor", cursor)
+			}
+
+			var list models.NotesListResponse
+			a.Decode(a.Request(http.MethodGet, "/api/v1/notes?"+query.Encode(), user.AccessToken, nil), http.StatusOK, &list)
+			pages++
+			if list.Total != int64(len(created)) {
+				t.Errorf("%s: total %d, se esperaba %d", order, list.Total, len(created))
+			}
+			if len(list.Notes) > 3 {
+				t.Fatalf("%s: página de %d notas con límite 3", order, len(list.Notes))
+			}
+
+			for _, note := range list.Notes {
+				if seen[note.ID] {
+					t.Fatalf("%s: la nota %d se repite", order, note.ID)
+				}
+				seen[note.ID] = true
+				// Every note is created after the previous one
+				if previous != 0 && (order == "desc") != (note.ID < previous) {
+					t.Errorf("%s: la nota %d va después de la %d", order, note.ID, previous)
+				}
+				previous = note.ID
+			}
+
+			if !list.HasMore {
+				if list.NextCursor != "" {
+					t.Errorf("%s: la última página tiene cursor", order)
+				}
+				break
+			}
+			if list.NextCursor == "" {
+				t.Fatalf("%s: página intermedia sin cursor", order)
+			}
+			cursor = list.NextCursor
+		}
+
+		if pages != 3 || len(seen) != len(created) {
+			t.Errorf("%s: %d notas en %d páginas, se esperaban %d en 3", order, len(seen), pages, len(created))
+		}
+	}
+
+	// A cursor only works with the order it was issued for
+	var list models.NotesListResponse
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes?limit=3", user.AccessToken, nil), http.StatusOK, &list)
+	var resp models.ErrorResponse
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes?limit=3&order=asc&cursor="+url.QueryEscape(list.NextCursor), user.AccessToken, nil), http.StatusBadRequest, &resp)
+	if resp.Code != "invalid_cursor" {
+		t.Errorf("código %q, se esperaba invalid_cursor", resp.Code)
+	}
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes?cursor=basura", user.AccessToken, nil), http.StatusBadRequest, nil)
+}
+
+// postForm submits an HTML form to path with the access token
+func postForm(a *apptest.App, path string, token string, locale string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept-Language", locale)
+	return a.Do(req)
+}
+
+func TestNoteFormsTranslateErrors(t *testing.T) {
+	a := apptest.New(t)
+	owner := a.SignUp("owner")
+	other := a.SignUp("other")
+	note := createNote(t, a, owner.AccessToken, "Nota")
+	id := strconv.Itoa(note.ID)
+
+	forms := []struct {
+		path   string
+		form   url.Values
+		status int
+		es     string
+		en     string
+	}{
+		{"/notes/update", url.Values{"id": {"abc"}, "version": {"1"}}, http.StatusBadRequest, "ID inválido", "Invalid ID"},
+		{"/notes/update", url.Values{"id": {id}, "version": {"uno"}}, http.StatusBadRequest, "Datos inválidos", "Invalid data"},
+		{"/notes/update", url.Values{"id": {id}, "version": {"1"}, "title": {"Ajena"}}, http.StatusNotFound, "Nota no encontrada", "Note not found"},
+		{"/notes/delete", url.Values{"id": {id}, "version": {"1"}}, http.StatusNotFound, "Nota no encontrada", "Note not found"},
+	}
+	for _, f := range forms {
+		for locale, message := range map[string]string{"es": f.es, "en": f.en} {
+			rec := postForm(a, f.path, other.AccessToken, locale, f.form)
+			if rec.Code != f.status || !strings.Contains(rec.Body.String(), message) {
+				t.Errorf("%s %v (%s): estado %d, se esperaba %d con %q: %s", f.path, f.form, locale, rec.Code, f.status, message, rec.Body.String())
+			}
+		}
+	}
+}
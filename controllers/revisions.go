@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"notasGo/i18n"
+	"notasGo/models"
+	"notasGo/services"
+	"notasGo/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RevisionController struct {
+	revisionService *services.RevisionService
+}
+
+func NewRevisionController(revisionService *services.RevisionService) *RevisionController {
+	return &RevisionController{
+		revisionService: revisionService,
+	}
+}
+
+// GetRevisions godoc
+// @Summary Obtiene el historial de una nota
+// @Description Devuelve las revisiones de una nota, de la más reciente a la más antigua. Cada revisión guarda el contenido previo a una edición
+// @Tags revisiones
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID de la nota"
+// @Success 200 {object} models.RevisionsListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notes/{id}/revisions [get]
+func (ctrl *RevisionController) GetRevisions(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	revisions, err := ctrl.revisionService.GetRevisions(c.Request.Context(), id, caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	revisionResponses := make([]models.RevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		revisionResponses = append(revisionResponses, models.RevisionResponse{
+			NoteID:    revision.NoteID,
+			Number:    revision.Number,
+			Title:     revision.Title,
+			Content:   revision.Content,
+			AuthorID:  revision.AuthorID,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	response := models.RevisionsListResponse{
+		Success:   true,
+		Message:   i18n.T(c, "revision.listed"),
+		Revisions: revisionResponses,
+		Total:     len(revisionResponses),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRevision godoc
+// @Summary Obtiene una revisión de una nota
+// @Description Devuelve el título y contenido guardados en una revisión concreta
+// @Tags revisiones
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID de la nota"
+// @Param rev path int true "Número de revisión"
+// @Success 200 {object} models.APIResponse{data=models.RevisionResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notes/{id}/revisions/{rev} [get]
+func (ctrl *RevisionController) GetRevision(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	number, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	revision, err := ctrl.revisionService.GetRevision(c.Request.Context(), id, number, caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	revisionResponse := models.RevisionResponse{
+		NoteID:    revision.NoteID,
+		Number:    revision.Number,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		AuthorID:  revision.AuthorID,
+		CreatedAt: revision.CreatedAt,
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "revision.retrieved", revisionResponse)
+}
+
+// DiffRevisions godoc
+// @Summary Compara dos revisiones de una nota
+// @Description Devuelve el diff unificado del contenido entre dos revisiones. Usa "current" para comparar con el contenido actual. Si difieren en más de 2000 líneas responde 400 diff_too_large
+// @Tags revisiones
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID de la nota"
+// @Param from query string true "Revisión de origen o current"
+// @Param to query string false "Revisión de destino o current" default(current)
+// @Success 200 {object} models.RevisionDiffResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notes/{id}/revisions/diff [get]
+func (ctrl *RevisionController) DiffRevisions(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	from := c.Query("from")
+	to := c.DefaultQuery("to", services.CurrentRevision)
+	if from == "" {
+		c.Error(services.ErrInvalidQuery.WithMessage("error.invalid_query.from_required"))
+		return
+	}
+	for _, value := range []string{from, to} {
+		if value == services.CurrentRevision {
+			continue
+		}
+		if _, err := parseRevision(value); err != nil {
+			c.Error(services.ErrInvalidQuery.WithMessage("error.invalid_query.revision").Wrap(err))
+			return
+		}
+	}
+
+	diff, err := ctrl.revisionService.DiffRevisions(c.Request.Context(), id, from, to, caller, i18n.Locale(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := models.RevisionDiffResponse{
+		Success:   true,
+		Message:   i18n.T(c, "revision.diffed"),
+		From:      diff.From,
+		To:        diff.To,
+		FromTitle: diff.FromTitle,
+		ToTitle:   diff.ToTitle,
+		Diff:      diff.Diff,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreRevision godoc
+// @Summary Restaura una revisión de una nota
+// @Description Vuelve al título y contenido de una revisión. El contenido reemplazado se guarda como una nueva revisión, el historial no se reescribe
+// @Tags revisiones
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID de la nota"
+// @Param rev path int true "Número de revisión"
+// @Param If-Match header string true "ETag obtenido al leer la nota"
+// @Success 200 {object} models.APIResponse{data=models.NoteResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notes/{id}/revisions/{rev}/restore [post]
+func (ctrl *RevisionController) RestoreRevision(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	number, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
+	expectedVersions, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	note, err := ctrl.revisionService.RestoreRevision(c.Request.Context(), id, number, caller, expectedVersions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userResponse := models.UserResponse{
+		ID:        note.User.ID,
+		Username:  note.User.Username,
+		Email:     note.User.Email,
+		Role:      note.User.Role,
+		Status:    note.User.Status,
+		CreatedAt: note.User.CreatedAt,
+		UpdatedAt: note.User.UpdatedAt,
+	}
+
+	noteResponse := models.NoteResponse{
+		ID:        note.ID,
+		Title:     note.Title,
+		Content:   note.Content,
+		UserID:    note.UserID,
+		User:      userResponse,
+		Tags:      tagNames(note.Tags),
+		Version:   note.Version,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+
+	c.Header("ETag", noteETag(note))
+	utils.SuccessResponse(c, http.StatusOK, "revision.restored", noteResponse)
+}
+
+// parseRevision converts a revision number parameter, numbers start at 1
+func parseRevision(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if number < 1 {
+		return 0, fmt.Errorf("número de revisión fuera de rango: %d", number)
+	}
+	return number, nil
+}
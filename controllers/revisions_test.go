@@ -0,0 +1,105 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"notasGo/app/apptest"
+	"notasGo/models"
+	"notasGo/utils"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns count lines starting with prefix
+func numberedLines(prefix string, count int) string {
+	var b strings.Builder
+	for i := range count {
+		fmt.Fprintf(&b, "%s %d\n", prefix, i)
+	}
+	return b.String()
+}
+
+func TestRevisionDiffIsCapped(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Nota")
+	path := fmt.Sprintf("/api/v1/notes/%d", note.ID)
+
+	update := func(version int, content string) {
+		t.Helper()
+		req := a.NewRequest(http.MethodPatch, path, user.AccessToken, map[string]any{"content": content})
+		req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, version))
+		a.Decode(a.Do(req), http.StatusOK, nil)
+	}
+	diffPath := path + "/revisions/diff?from=%d&to=current"
+
+	// Two texts without a line in common are refused past the limit
+	first := numberedLines("a", utils.MaxDiffLines+1)
+	update(1, first)
+	update(2, numberedLines("b", utils.MaxDiffLines+1))
+	rec := a.Request(http.MethodGet, fmt.Sprintf(diffPath, 2), user.AccessToken, nil)
+	expectError(t, a, rec, http.StatusBadRequest, "diff_too_large")
+
+	// Long texts are compared when the change itself is small
+	changed := strings.Replace(first, "a 1000\n", "cambiada\n", 1)
+	update(3, first)
+	update(4, changed)
+	var resp models.RevisionDiffResponse
+	a.Decode(a.Request(http.MethodGet, fmt.Sprintf(diffPath, 4), user.AccessToken, nil), http.StatusOK, &resp)
+	want := "@@ -998,7 +998,7 @@\n a 997\n a 998\n a 999\n-a 1000\n+cambiada\n a 1001\n a 1002\n a 1003\n"
+	if !strings.HasSuffix(resp.Diff, want) {
+		t.Errorf("diff inesperado:\n%s", resp.Diff)
+	}
+}
+
+func TestNoteContentIsCapped(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Nota")
+	path := fmt.Sprintf("/api/v1/notes/%d", note.ID)
+	long := strings.Repeat("á", models.MaxNoteContentLength+1)
+
+	rec := a.Request(http.MethodPost, "/api/v1/notes", user.AccessToken, models.CreateNoteRequest{Title: "Larga", Content: long})
+	expectError(t, a, rec, http.StatusBadRequest, "invalid_request")
+	req := a.NewRequest(http.MethodPut, path, user.AccessToken, map[string]any{"content": long})
+	req.Header.Set("If-Match", `"1"`)
+	expectError(t, a, a.Do(req), http.StatusBadRequest, "invalid_request")
+
+	// PATCH takes any field map, so the service checks the length itself
+	req = a.NewRequest(http.MethodPatch, path, user.AccessToken, map[string]any{"content": long})
+	req.Header.Set("If-Match", `"1"`)
+	var resp models.ErrorResponse
+	a.Decode(a.Do(req), http.StatusBadRequest, &resp)
+	if resp.Code != "content_too_long" || len(resp.Fields) != 1 || resp.Fields[0].Field != "content" {
+		t.Errorf("error %+v, se esperaba content_too_long en content", resp)
+	}
+
+	// The limit counts characters, not bytes
+	req = a.NewRequest(http.MethodPatch, path, user.AccessToken, map[string]any{"content": long[len("á"):]})
+	req.Header.Set("If-Match", `"1"`)
+	a.Decode(a.Do(req), http.StatusOK, nil)
+}
+
+func TestRevisionDiffNamesTheSidesInTheLocale(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	note := createNote(t, a, user.AccessToken, "Nota")
+	path := fmt.Sprintf("/api/v1/notes/%d", note.ID)
+
+	req := a.NewRequest(http.MethodPatch, path, user.AccessToken, map[string]any{"content": "cambiada"})
+	req.Header.Set("If-Match", `"1"`)
+	a.Decode(a.Do(req), http.StatusOK, nil)
+
+	for locale, header := range map[string]string{
+		"es": "--- revisión 1\n+++ actual\n",
+		"en": "--- revision 1\n+++ current\n",
+	} {
+		req := a.NewRequest(http.MethodGet, path+"/revisions/diff?from=1", user.AccessToken, nil)
+		req.Header.Set("Accept-Language", locale)
+		var resp models.RevisionDiffResponse
+		a.Decode(a.Do(req), http.StatusOK, &resp)
+		if !strings.HasPrefix(resp.Diff, header) {
+			t.Errorf("%s: diff inesperado:\n%s", locale, resp.Diff)
+		}
+	}
+}
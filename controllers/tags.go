@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+	"notasGo/i18n"
+	"notasGo/models"
+	"notasGo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagController struct {
+	tagService *services.TagService
+}
+
+func NewTagController(tagService *services.TagService) *TagController {
+	return &TagController{
+		tagService: tagService,
+	}
+}
+
+// GetTags godoc
+// @Summary Obtiene las etiquetas en uso
+// @Description Devuelve las etiquetas de las notas visibles por el usuario autenticado con el número de notas que usan cada una
+// @Tags etiquetas
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TagsListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tags [get]
+func (ctrl *TagController) GetTags(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	usages, err := ctrl.tagService.GetTags(c.Request.Context(), caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tagResponses := make([]models.TagResponse, 0, len(usages))
+	for _, usage := range usages {
+		tagResponses = append(tagResponses, models.TagResponse{
+			Name:  usage.Name,
+			Count: usage.Count,
+		})
+	}
+
+	response := models.TagsListResponse{
+		Success: true,
+		Message: i18n.T(c, "tag.listed"),
+		Tags:    tagResponses,
+		Total:   len(tagResponses),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"net/http"
+	"notasGo/i18n"
+	"notasGo/models"
+	"notasGo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrashController struct {
+	trashService *services.TrashService
+}
+
+func NewTrashController(trashService *services.TrashService) *TrashController {
+	return &TrashController{
+		trashService: trashService,
+	}
+}
+
+// GetTrash godoc
+// @Summary Obtiene la papelera
+// @Description Devuelve las notas eliminadas visibles por el usuario autenticado y, para administradores, las cuentas eliminadas. Cada elemento indica cuándo se borrará definitivamente
+// @Tags papelera
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TrashResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/trash [get]
+func (ctrl *TrashController) GetTrash(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	notes, users, err := ctrl.trashService.GetTrash(c.Request.Context(), caller)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	retention := ctrl.trashService.Retention()
+
+	noteResponses := make([]models.TrashedNoteResponse, 0, len(notes))
+	for _, note := range notes {
+		noteResponses = append(noteResponses, models.TrashedNoteResponse{
+			ID:        note.ID,
+			Title:     note.Title,
+			UserID:    note.UserID,
+			Tags:      tagNames(note.Tags),
+			DeletedAt: note.DeletedAt.Time,
+			PurgeAt:   note.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	userResponses := make([]models.TrashedUserResponse, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, models.TrashedUserResponse{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			DeletedAt: user.DeletedAt.Time,
+			PurgeAt:   user.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	response := models.TrashResponse{
+		Success: true,
+		Message: i18n.T(c, "trash.listed"),
+		Notes:   noteResponses,
+		Users:   userResponses,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,36 +1,77 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"notasGo/i18n"
+	"notasGo/middleware"
 	"notasGo/models"
+	"notasGo/policy"
 	"notasGo/services"
 	"notasGo/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserController struct {
-	userService *services.UserService
+	userService          *services.UserService
+	sessionService       *services.SessionService
+	lockoutService       *services.LockoutService
+	verificationService  *services.VerificationService
+	passwordResetService *services.PasswordResetService
+	tokenService         *services.TokenService
 }
 
-func NewUserController() *UserController {
+func NewUserController(userService *services.UserService, sessionService *services.SessionService, lockoutService *services.LockoutService, verificationService *services.VerificationService, passwordResetService *services.PasswordResetService, tokenService *services.TokenService) *UserController {
 	return &UserController{
-		userService: services.NewUserService(),
+		userService:          userService,
+		sessionService:       sessionService,
+		lockoutService:       lockoutService,
+		verificationService:  verificationService,
+		passwordResetService: passwordResetService,
+		tokenService:         tokenService,
 	}
 }
 
 // GetUsers godoc
 // @Summary Obtiene todos los usuarios
-// @Description Devuelve una lista de todos los usuarios registrados
+// @Description Devuelve una lista de todos los usuarios registrados (solo administradores)
 // @Tags usuarios
 // @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Tamaño de página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor devuelto en next_cursor"
+// @Param page query int false "Número de página (alternativa al cursor)"
+// @Param sort query string false "Campo de orden" Enums(created_at, updated_at, username) default(created_at)
+// @Param order query string false "Dirección del orden" Enums(asc, desc) default(desc)
+// @Param role query string false "Filtrar por rol" Enums(user, admin)
+// @Param status query string false "Filtrar por estado" Enums(activo, inactivo, pendiente)
 // @Success 200 {object} models.UsersListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users [get]
 func (ctrl *UserController) GetUsers(c *gin.Context) {
-	users, total, err := ctrl.userService.GetAllUsers()
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !policy.CanListUsers(caller) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.list_users"))
+		return
+	}
+
+	var query models.UserListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(services.ErrInvalidQuery.Wrap(err))
+		return
+	}
+
+	users, total, page, err := ctrl.userService.GetAllUsers(c.Request.Context(), &query)
 	if err != nil {
-		utils.InternalServerError(c, "Error al obtener usuarios", err)
+		c.Error(err)
 		return
 	}
 
@@ -49,10 +90,13 @@ func (ctrl *UserController) GetUsers(c *gin.Context) {
 	}
 
 	response := models.UsersListResponse{
-		Success: true,
-		Message: "Usuarios obtenidos exitosamente",
-		Users:   userResponses,
-		Total:   total,
+		Success:    true,
+		Message:    i18n.T(c, "user.listed"),
+		Users:      userResponses,
+		Total:      total,
+		Limit:      page.Limit,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -60,24 +104,36 @@ func (ctrl *UserController) GetUsers(c *gin.Context) {
 
 // GetUserByID godoc
 // @Summary Obtiene un usuario por ID
-// @Description Devuelve la información de un usuario específico
+// @Description Devuelve la información de un usuario específico (el propio usuario o un administrador)
 // @Tags usuarios
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID del usuario"
 // @Success 200 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/{id} [get]
 func (ctrl *UserController) GetUserByID(c *gin.Context) {
-	id := c.Param("id")
-	
-	user, err := ctrl.userService.GetUserByID(id)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := parseID(c.Param("id"))
 	if err != nil {
-		if err.Error() == "usuario no encontrado" {
-			utils.NotFoundError(c, "Usuario no encontrado")
-			return
-		}
-		utils.InternalServerError(c, "Error al obtener usuario", err)
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	if !policy.CanViewUser(caller, targetID) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.view_user"))
+		return
+	}
+
+	user, err := ctrl.userService.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -91,12 +147,12 @@ func (ctrl *UserController) GetUserByID(c *gin.Context) {
 		UpdatedAt: user.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Usuario obtenido exitosamente", userResponse)
+	utils.SuccessResponse(c, http.StatusOK, "user.retrieved", userResponse)
 }
 
 // RegisterUser godoc
 // @Summary Registra un nuevo usuario
-// @Description Crea una nueva cuenta de usuario con encriptación de contraseña
+// @Description Crea una nueva cuenta de usuario con encriptación de contraseña. La cuenta queda pendiente hasta que se sigue el enlace de verificación enviado al email
 // @Tags usuarios
 // @Accept json
 // @Produce json
@@ -104,22 +160,19 @@ func (ctrl *UserController) GetUserByID(c *gin.Context) {
 // @Success 201 {object} models.APIResponse{data=models.UserResponse}
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Demasiados intentos; la cabecera Retry-After indica los segundos de espera"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /register [post]
 func (ctrl *UserController) RegisterUser(c *gin.Context) {
 	var req models.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	user, err := ctrl.userService.CreateUser(&req)
+	user, err := ctrl.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
-		if err.Error() == "el email ya está registrado" || err.Error() == "el nombre de usuario ya está en uso" {
-			utils.ConflictError(c, err.Error(), nil)
-			return
-		}
-		utils.InternalServerError(c, "Error al crear usuario", err)
+		c.Error(err)
 		return
 	}
 
@@ -133,43 +186,52 @@ func (ctrl *UserController) RegisterUser(c *gin.Context) {
 		UpdatedAt: user.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Usuario registrado exitosamente", userResponse)
+	utils.SuccessResponse(c, http.StatusCreated, "user.registered", userResponse)
 }
 
 // UpdateUser godoc
 // @Summary Actualiza un usuario
-// @Description Actualiza la información de un usuario existente
+// @Description Actualiza la información de un usuario existente. Los usuarios solo pueden editar su propio perfil; cambiar rol o estado requiere ser administrador. Cambiar el email deja la cuenta pendiente de verificar el nuevo
 // @Tags usuarios
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID del usuario"
 // @Param user body models.UpdateUserRequest true "Datos a actualizar"
 // @Success 200 {object} models.APIResponse{data=models.UserResponse}
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/{id} [put]
 func (ctrl *UserController) UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-	
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+
 	var req models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	user, err := ctrl.userService.UpdateUser(id, &req)
+	if !policy.CanUpdateUser(caller, targetID, &req) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.update_user"))
+		return
+	}
+
+	user, err := ctrl.userService.UpdateUser(c.Request.Context(), targetID, &req)
 	if err != nil {
-		if err.Error() == "usuario no encontrado" {
-			utils.NotFoundError(c, "Usuario no encontrado")
-			return
-		}
-		if err.Error() == "el email ya está en uso por otro usuario" || err.Error() == "el nombre de usuario ya está en uso" {
-			utils.ConflictError(c, err.Error(), nil)
-			return
-		}
-		utils.InternalServerError(c, "Error al actualizar usuario", err)
+		c.Error(err)
 		return
 	}
 
@@ -183,38 +245,193 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 		UpdatedAt: user.UpdatedAt,
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Usuario actualizado exitosamente", userResponse)
+	utils.SuccessResponse(c, http.StatusOK, "user.updated", userResponse)
 }
 
 // DeleteUser godoc
 // @Summary Elimina un usuario
-// @Description Elimina un usuario y todas sus notas asociadas
+// @Description Mueve un usuario y todas sus notas a la papelera. Eliminar otras cuentas requiere ser administrador
 // @Tags usuarios
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID del usuario"
 // @Success 200 {object} models.APIResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users/{id} [delete]
 func (ctrl *UserController) DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	
-	err := ctrl.userService.DeleteUser(id)
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := parseID(c.Param("id"))
 	if err != nil {
-		if err.Error() == "usuario no encontrado" {
-			utils.NotFoundError(c, "Usuario no encontrado")
-			return
-		}
-		utils.InternalServerError(c, "Error al eliminar usuario", err)
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	if !policy.CanDeleteUser(caller, targetID) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.delete_user"))
+		return
+	}
+
+	err = ctrl.userService.DeleteUser(c.Request.Context(), targetID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "user.trashed", nil)
+}
+
+// RestoreUser godoc
+// @Summary Restaura un usuario de la papelera
+// @Description Saca de la papelera una cuenta eliminada junto con las notas que se eliminaron con ella. Requiere ser administrador
+// @Tags papelera
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID del usuario"
+// @Success 200 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/{id}/restore [post]
+func (ctrl *UserController) RestoreUser(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return
+	}
+	if !policy.CanRestoreUser(caller) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.restore_user"))
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Usuario y sus notas eliminados exitosamente", nil)
+	user, err := ctrl.userService.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userResponse := models.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		Status:    user.Status,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "user.restored", userResponse)
+}
+
+// GetLockout godoc
+// @Summary Obtiene el bloqueo de login de un usuario
+// @Description Devuelve los intentos fallidos seguidos del email del usuario y hasta cuándo se rechazan sus logins. Requiere ser administrador
+// @Tags usuarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID del usuario"
+// @Success 200 {object} models.APIResponse{data=models.LockoutResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/{id}/lockout [get]
+func (ctrl *UserController) GetLockout(c *gin.Context) {
+	user, ok := ctrl.lockoutTarget(c)
+	if !ok {
+		return
+	}
+
+	lockout, err := ctrl.lockoutService.GetLockout(c.Request.Context(), user.Email)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := models.LockoutResponse{
+		Email:       user.Email,
+		Failures:    lockout.Failures,
+		Locked:      lockout.IsLocked(time.Now()),
+		LockedUntil: lockout.LockedUntil,
+	}
+	if !lockout.LastFailureAt.IsZero() {
+		response.LastFailureAt = &lockout.LastFailureAt
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "user.lockout_retrieved", response)
+}
+
+// ClearLockout godoc
+// @Summary Desbloquea el login de un usuario
+// @Description Olvida los intentos fallidos del email del usuario para que pueda iniciar sesión de inmediato. Requiere ser administrador
+// @Tags usuarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID del usuario"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/{id}/lockout [delete]
+func (ctrl *UserController) ClearLockout(c *gin.Context) {
+	user, ok := ctrl.lockoutTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.lockoutService.ClearLockout(c.Request.Context(), user.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "user.lockout_cleared", nil)
+}
+
+// lockoutTarget loads the user whose lockout an admin manages. It writes
+// the error and returns false when the caller is not allowed or the user
+// does not exist.
+func (ctrl *UserController) lockoutTarget(c *gin.Context) (*models.User, bool) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrInvalidID.Wrap(err))
+		return nil, false
+	}
+	if !policy.CanManageLockouts(caller) {
+		c.Error(services.ErrForbidden.WithMessage("error.forbidden.manage_lockouts"))
+		return nil, false
+	}
+
+	user, err := ctrl.userService.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return user, true
 }
 
 // LoginUser godoc
 // @Summary Autenticación de usuario
-// @Description Autentica un usuario con email y contraseña
+// @Description Autentica un usuario con email y contraseña y emite un token de acceso firmado junto con un refresh token
 // @Tags usuarios
 // @Accept json
 // @Produce json
@@ -222,22 +439,126 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 // @Success 200 {object} models.LoginResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Cuenta inactiva o email sin verificar"
+// @Failure 429 {object} models.ErrorResponse "Demasiados intentos; la cabecera Retry-After indica los segundos de espera"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /login [post]
 func (ctrl *UserController) LoginUser(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequestError(c, "Datos inválidos", err)
+		c.Error(services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	user, err := ctrl.userService.AuthenticateUser(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	session, refreshToken, err := ctrl.sessionService.CreateSession(c.Request.Context(), user.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctrl.respondWithTokens(c, user, session.ID, refreshToken, "auth.logged_in")
+}
+
+// RefreshToken godoc
+// @Summary Renueva el token de acceso
+// @Description Intercambia un refresh token válido por un nuevo token de acceso. El refresh token se rota y el anterior deja de ser válido
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/auth/refresh [post]
+func (ctrl *UserController) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	session, err := ctrl.sessionService.FindSession(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	user, err := ctrl.userService.AuthenticateUser(&req)
+	// The user is checked first, so a refused refresh leaves the token as it was
+	user, err := ctrl.userService.GetUserByID(c.Request.Context(), session.UserID)
 	if err != nil {
-		if err.Error() == "credenciales inválidas" || err.Error() == "cuenta inactiva" {
-			utils.UnauthorizedError(c, err.Error())
-			return
+		if errors.Is(err, services.ErrUserNotFound) {
+			err = services.ErrInvalidRefreshToken
 		}
-		utils.InternalServerError(c, "Error en el proceso de autenticación", err)
+		c.Error(err)
+		return
+	}
+	if user.Status != "activo" {
+		c.Error(services.ErrAccountInactive)
+		return
+	}
+
+	refreshToken, err := ctrl.sessionService.RotateSession(c.Request.Context(), session)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctrl.respondWithTokens(c, user, session.ID, refreshToken, "auth.refreshed")
+}
+
+// LogoutUser godoc
+// @Summary Cierra la sesión del usuario
+// @Description Revoca todas las sesiones del usuario autenticado; sus tokens de acceso y refresh tokens dejan de ser válidos
+// @Tags usuarios
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.APIResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/auth/logout [post]
+func (ctrl *UserController) LogoutUser(c *gin.Context) {
+	caller, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.sessionService.RevokeUserSessions(c.Request.Context(), caller.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.SetCookie(middleware.AccessTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	utils.SuccessResponse(c, http.StatusOK, "auth.logged_out", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verifica el email de una cuenta
+// @Description Activa la cuenta pendiente a la que se envió el token. Cada token sirve una sola vez y caduca
+// @Tags usuarios
+// @Produce json
+// @Param token query string true "Token recibido por email"
+// @Success 200 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/auth/verify [get]
+func (ctrl *UserController) VerifyEmail(c *gin.Context) {
+	var query models.VerifyEmailQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(services.ErrInvalidQuery.Wrap(err))
+		return
+	}
+
+	user, err := ctrl.verificationService.VerifyEmail(c.Request.Context(), query.Token)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -251,11 +572,111 @@ func (ctrl *UserController) LoginUser(c *gin.Context) {
 		UpdatedAt: user.UpdatedAt,
 	}
 
+	utils.SuccessResponse(c, http.StatusOK, "auth.email_verified", userResponse)
+}
+
+// ResendVerification godoc
+// @Summary Reenvía el email de verificación
+// @Description Envía un nuevo enlace de verificación si el email pertenece a una cuenta pendiente; los enlaces anteriores dejan de servir. La respuesta es la misma exista o no la cuenta, y el email se envía en segundo plano
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param request body models.ResendVerificationRequest true "Email de la cuenta"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Demasiados intentos; la cabecera Retry-After indica los segundos de espera"
+// @Router /api/v1/auth/verify/resend [post]
+func (ctrl *UserController) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	ctrl.verificationService.ResendVerification(c.Request.Context(), req.Email)
+	utils.SuccessResponse(c, http.StatusOK, "auth.verification_sent", nil)
+}
+
+// ForgotPassword godoc
+// @Summary Solicita restablecer la contraseña
+// @Description Envía al email un token para elegir una nueva contraseña; los tokens anteriores dejan de servir. La respuesta es la misma exista o no la cuenta, y el token se crea y envía en segundo plano
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Email de la cuenta"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Demasiados intentos; la cabecera Retry-After indica los segundos de espera"
+// @Router /api/v1/auth/forgot-password [post]
+func (ctrl *UserController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	ctrl.passwordResetService.RequestReset(c.Request.Context(), req.Email)
+	utils.SuccessResponse(c, http.StatusOK, "auth.password_reset_sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Restablece la contraseña
+// @Description Cambia la contraseña de la cuenta a la que se envió el token. Cada token sirve una sola vez y caduca. Todas las sesiones de la cuenta se cierran
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Token recibido por email y nueva contraseña"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Demasiados intentos; la cabecera Retry-After indica los segundos de espera"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/auth/reset-password [post]
+func (ctrl *UserController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if err := ctrl.passwordResetService.ResetPassword(c.Request.Context(), &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "auth.password_reset", nil)
+}
+
+// respondWithTokens issues an access token for the session and writes the login response
+func (ctrl *UserController) respondWithTokens(c *gin.Context, user *models.User, sessionID uint, refreshToken string, messageID string) {
+	accessToken, _, err := ctrl.tokenService.GenerateAccessToken(user.ID, user.Role, sessionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Cookie used by the HTML dashboard, API clients use the bearer token
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(middleware.AccessTokenCookie, accessToken, int(ctrl.tokenService.AccessTTL().Seconds()), "/", "", c.Request.TLS != nil, true)
+
+	userResponse := models.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		Status:    user.Status,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
 	response := models.LoginResponse{
-		Success: true,
-		Message: "Login exitoso",
-		User:    userResponse,
+		Success:      true,
+		Message:      i18n.T(c, messageID),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(ctrl.tokenService.AccessTTL().Seconds()),
+		User:         userResponse,
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
@@ -0,0 +1,436 @@
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"notasGo/app/apptest"
+	"notasGo/config"
+	"notasGo/models"
+	"notasGo/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+
+	var refreshed models.LoginResponse
+	rec := a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+	a.Decode(rec, http.StatusOK, &refreshed)
+	if refreshed.AccessToken == "" || refreshed.RefreshToken == "" || refreshed.RefreshToken == user.RefreshToken {
+		t.Fatal("el refresh no devolvió tokens nuevos")
+	}
+	if refreshed.User.ID != user.ID {
+		t.Errorf("tokens del usuario %d, se esperaba %d", refreshed.User.ID, user.ID)
+	}
+
+	// The old refresh token stops working as soon as it is rotated
+	var resp models.ErrorResponse
+	rec = a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+	a.Decode(rec, http.StatusUnauthorized, &resp)
+	if resp.Code != "invalid_refresh_token" {
+		t.Errorf("código %q, se esperaba invalid_refresh_token", resp.Code)
+	}
+
+	// Both access tokens belong to the same session, which stays open
+	for _, token := range []string{user.AccessToken, refreshed.AccessToken} {
+		a.Decode(a.Request(http.MethodGet, "/api/v1/notes", token, nil), http.StatusOK, nil)
+	}
+
+	var again models.LoginResponse
+	rec = a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	a.Decode(rec, http.StatusOK, &again)
+}
+
+func TestRefreshChecksTheUserFirst(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+
+	// Changing the email leaves the account pending without closing its sessions
+	path := fmt.Sprintf("/api/v1/users/%d", user.ID)
+	a.Decode(a.Request(http.MethodPut, path, user.AccessToken, models.UpdateUserRequest{Email: "nuevo@example.com"}), http.StatusOK, nil)
+	rec := a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+	expectError(t, a, rec, http.StatusForbidden, "account_inactive")
+
+	// The refused refresh did not spend the token
+	a.Decode(verify(a, a.VerificationToken("nuevo@example.com")), http.StatusOK, nil)
+	rec = a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+	a.Decode(rec, http.StatusOK, nil)
+}
+
+func TestLogoutRevokesEverySession(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+	other := a.Login(user.Email, apptest.Password)
+
+	a.Decode(a.Request(http.MethodPost, "/api/v1/auth/logout", user.AccessToken, nil), http.StatusOK, nil)
+
+	var resp models.ErrorResponse
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes", user.AccessToken, nil), http.StatusUnauthorized, &resp)
+	if resp.Code != "session_revoked" {
+		t.Errorf("código %q, se esperaba session_revoked", resp.Code)
+	}
+
+	// The sessions opened elsewhere are closed as well
+	for _, refreshToken := range []string{user.RefreshToken, other.RefreshToken} {
+		rec := a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshToken})
+		a.Decode(rec, http.StatusUnauthorized, nil)
+	}
+	a.Decode(a.Request(http.MethodGet, "/api/v1/notes", other.AccessToken, nil), http.StatusUnauthorized, nil)
+}
+
+func TestAccessTokensAreChecked(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("user")
+
+	for _, token := range []string{"", "basura", user.AccessToken + "x", user.RefreshToken} {
+		a.Decode(a.Request(http.MethodGet, "/api/v1/notes", token, nil), http.StatusUnauthorized, nil)
+	}
+}
+
+// login attempts a login and returns the response
+func login(a *apptest.App, email string, password string) *httptest.ResponseRecorder {
+	return a.Request(http.MethodPost, "/api/v1/auth/login", "", models.LoginRequest{Email: email, Password: password})
+}
+
+// expectError checks that rec is an error with the given status and code
+func expectError(t *testing.T, a *apptest.App, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	var resp models.ErrorResponse
+	a.Decode(rec, status, &resp)
+	if resp.Code != code {
+		t.Errorf("código %q, se esperaba %s", resp.Code, code)
+	}
+}
+
+func TestLoginDelaysRepeatedFailures(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 10
+		cfg.Auth.LockoutDuration = 15 * time.Minute
+	})
+	user := a.SignUp("user")
+
+	// Unknown emails are delayed the same way, so the delays do not tell
+	// which accounts exist
+	for _, email := range []string{user.Email, "nadie@example.com"} {
+		for range 4 {
+			expectError(t, a, login(a, email, "incorrecta"), http.StatusUnauthorized, "invalid_credentials")
+		}
+
+		// Even the right password waits for the delay
+		rec := login(a, email, apptest.Password)
+		expectError(t, a, rec, http.StatusTooManyRequests, "account_locked")
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("%s: Retry-After %q, se esperaba 1", email, got)
+		}
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	a.Decode(login(a, user.Email, apptest.Password), http.StatusOK, nil)
+
+	// The success forgets the failures
+	expectError(t, a, login(a, user.Email, "incorrecta"), http.StatusUnauthorized, "invalid_credentials")
+	expectError(t, a, login(a, user.Email, "incorrecta"), http.StatusUnauthorized, "invalid_credentials")
+	a.Decode(login(a, user.Email, apptest.Password), http.StatusOK, nil)
+}
+
+func TestLoginLocksAtTheThreshold(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 3
+		cfg.Auth.LockoutDuration = time.Hour
+	})
+	user := a.SignUp("user")
+	admin := a.SignUp("admin")
+	makeAdmin(t, a, admin)
+
+	for range 3 {
+		expectError(t, a, login(a, user.Email, "incorrecta"), http.StatusUnauthorized, "invalid_credentials")
+	}
+	rec := login(a, user.Email, apptest.Password)
+	expectError(t, a, rec, http.StatusTooManyRequests, "account_locked")
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After %q, se esperaba 3600", got)
+	}
+
+	// An admin sees the lockout and lifts it
+	path := fmt.Sprintf("/api/v1/users/%d/lockout", user.ID)
+	var lockout struct {
+		Data models.LockoutResponse `json:"data"`
+	}
+	a.Decode(a.Request(http.MethodGet, path, admin.AccessToken, nil), http.StatusOK, &lockout)
+	if !lockout.Data.Locked || lockout.Data.Failures != 3 {
+		t.Errorf("bloqueo %+v, se esperaban 3 fallos y la cuenta bloqueada", lockout.Data)
+	}
+	a.Decode(a.Request(http.MethodDelete, path, user.AccessToken, nil), http.StatusForbidden, nil)
+	a.Decode(a.Request(http.MethodDelete, path, admin.AccessToken, nil), http.StatusOK, nil)
+	a.Decode(login(a, user.Email, apptest.Password), http.StatusOK, nil)
+}
+
+func TestLoginRevealsTheStatusOnlyWithThePassword(t *testing.T) {
+	a := apptest.New(t)
+	rec := a.Request(http.MethodPost, "/api/v1/auth/register", "", models.CreateUserRequest{Username: "pending", Email: "pending@example.com", Password: apptest.Password})
+	a.Decode(rec, http.StatusCreated, nil)
+	user := a.SignUp("user")
+	ctx := context.Background()
+	if _, err := a.Container.UserService.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Status: "inactivo"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, email := range []string{"pending@example.com", user.Email, "nadie@example.com"} {
+		expectError(t, a, login(a, email, "incorrecta"), http.StatusUnauthorized, "invalid_credentials")
+	}
+	expectError(t, a, login(a, "pending@example.com", apptest.Password), http.StatusForbidden, "email_not_verified")
+	expectError(t, a, login(a, user.Email, apptest.Password), http.StatusForbidden, "account_inactive")
+}
+
+// register registers username with its own email and apptest.Password,
+// without verifying it
+func register(t *testing.T, a *apptest.App, path string, username string) *httptest.ResponseRecorder {
+	t.Helper()
+	return a.Request(http.MethodPost, path, "", models.CreateUserRequest{Username: username, Email: username + "@example.com", Password: apptest.Password})
+}
+
+// verify follows the verification link carrying token
+func verify(a *apptest.App, token string) *httptest.ResponseRecorder {
+	return a.Request(http.MethodGet, "/api/v1/auth/verify?token="+token, "", nil)
+}
+
+// makeAdmin gives user the admin role
+func makeAdmin(t *testing.T, a *apptest.App, user *apptest.User) {
+	t.Helper()
+
+	if _, err := a.Container.UserService.UpdateUser(context.Background(), user.ID, &models.UpdateUserRequest{Role: models.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterAndVerifyThroughTheOutbox(t *testing.T) {
+	a := apptest.New(t)
+
+	var created struct {
+		Data models.UserResponse `json:"data"`
+	}
+	a.Decode(register(t, a, "/api/v1/auth/register", "ana"), http.StatusCreated, &created)
+	if created.Data.Status != "pendiente" {
+		t.Errorf("cuenta creada con estado %q, se esperaba pendiente", created.Data.Status)
+	}
+	expectError(t, a, login(a, "ana@example.com", apptest.Password), http.StatusForbidden, "email_not_verified")
+
+	token := a.VerificationToken("ana@example.com")
+	expectError(t, a, verify(a, token+"x"), http.StatusBadRequest, "invalid_verification_token")
+
+	var verified struct {
+		Data models.UserResponse `json:"data"`
+	}
+	a.Decode(verify(a, token), http.StatusOK, &verified)
+	if verified.Data.ID != created.Data.ID || verified.Data.Status != "activo" {
+		t.Errorf("verificada la cuenta %d con estado %q", verified.Data.ID, verified.Data.Status)
+	}
+
+	// A link works once
+	expectError(t, a, verify(a, token), http.StatusBadRequest, "invalid_verification_token")
+	a.Decode(login(a, "ana@example.com", apptest.Password), http.StatusOK, nil)
+}
+
+func TestResendVerification(t *testing.T) {
+	a := apptest.New(t)
+	a.Decode(register(t, a, "/register", "ana"), http.StatusCreated, nil)
+	first := a.VerificationToken("ana@example.com")
+
+	resend := func(email string) string {
+		t.Helper()
+		rec := a.Request(http.MethodPost, "/api/v1/auth/verify/resend", "", models.ResendVerificationRequest{Email: email})
+		a.Decode(rec, http.StatusOK, nil)
+		return rec.Body.String()
+	}
+
+	// The answer is the same whether the account exists or not
+	if resend("ANA@example.com") != resend("nadie@example.com") {
+		t.Error("la respuesta revela qué emails están registrados")
+	}
+
+	// The new link replaces the old one
+	second := a.VerificationToken("ana@example.com")
+	if second == first {
+		t.Fatal("no se envió un enlace nuevo")
+	}
+	expectError(t, a, verify(a, first), http.StatusBadRequest, "invalid_verification_token")
+	a.Decode(verify(a, second), http.StatusOK, nil)
+
+	// Verified accounts get no more links
+	resend("ana@example.com")
+	if got := a.VerificationToken("ana@example.com"); got != second {
+		t.Error("se envió un enlace a una cuenta ya verificada")
+	}
+}
+
+func TestEmailChangeNeedsVerification(t *testing.T) {
+	a := apptest.New(t)
+	admin := a.SignUp("admin")
+	makeAdmin(t, a, admin)
+
+	// A link still outstanding for the old address stops working
+	var created struct {
+		Data models.UserResponse `json:"data"`
+	}
+	a.Decode(register(t, a, "/api/v1/auth/register", "ana"), http.StatusCreated, &created)
+	old := a.VerificationToken("ana@example.com")
+	path := fmt.Sprintf("/api/v1/users/%d", created.Data.ID)
+	a.Decode(a.Request(http.MethodPut, path, admin.AccessToken, models.UpdateUserRequest{Email: "ana.nueva@example.com"}), http.StatusOK, nil)
+	expectError(t, a, verify(a, old), http.StatusBadRequest, "invalid_verification_token")
+	a.Decode(verify(a, a.VerificationToken("ana.nueva@example.com")), http.StatusOK, nil)
+
+	// An active account is pending again until the new address is verified
+	user := a.SignUp("luis")
+	var updated struct {
+		Data models.UserResponse `json:"data"`
+	}
+	path = fmt.Sprintf("/api/v1/users/%d", user.ID)
+	a.Decode(a.Request(http.MethodPut, path, user.AccessToken, models.UpdateUserRequest{Email: "luis.nuevo@example.com"}), http.StatusOK, &updated)
+	if updated.Data.Status != "pendiente" {
+		t.Errorf("estado %q tras cambiar el email, se esperaba pendiente", updated.Data.Status)
+	}
+	expectError(t, a, login(a, "luis.nuevo@example.com", apptest.Password), http.StatusForbidden, "email_not_verified")
+	a.Decode(verify(a, a.VerificationToken("luis.nuevo@example.com")), http.StatusOK, nil)
+	a.Decode(login(a, "luis.nuevo@example.com", apptest.Password), http.StatusOK, nil)
+}
+
+func TestRegisterIsRateLimited(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginIPRate = ratelimit.Rate{Limit: 2, Period: time.Minute}
+	})
+
+	a.Decode(register(t, a, "/api/v1/auth/register", "ana"), http.StatusCreated, nil)
+	a.Decode(register(t, a, "/register", "luis"), http.StatusCreated, nil)
+
+	// Both routes share the bucket of the login with the client IP
+	for _, path := range []string{"/api/v1/auth/register", "/register"} {
+		rec := register(t, a, path, "eva")
+		expectError(t, a, rec, http.StatusTooManyRequests, "too_many_requests")
+		if rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: 429 sin Retry-After", path)
+		}
+	}
+	expectError(t, a, login(a, "ana@example.com", apptest.Password), http.StatusTooManyRequests, "too_many_requests")
+}
+
+// forgotPassword asks for a reset token for email
+func forgotPassword(a *apptest.App, email string) *httptest.ResponseRecorder {
+	return a.Request(http.MethodPost, "/api/v1/auth/forgot-password", "", models.ForgotPasswordRequest{Email: email})
+}
+
+// resetPassword sets password with the reset token
+func resetPassword(a *apptest.App, token string, password string) *httptest.ResponseRecorder {
+	return a.Request(http.MethodPost, "/api/v1/auth/reset-password", "", models.ResetPasswordRequest{Token: token, Password: password})
+}
+
+func TestResetPasswordThroughTheOutbox(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 3
+		cfg.Auth.LockoutDuration = time.Hour
+	})
+	user := a.SignUp("ana")
+
+	// A locked account can still reset its password
+	for range 3 {
+		login(a, user.Email, "incorrecta")
+	}
+	expectError(t, a, login(a, user.Email, apptest.Password), http.StatusTooManyRequests, "account_locked")
+
+	a.Decode(forgotPassword(a, "ANA@example.com"), http.StatusOK, nil)
+	first := a.ResetToken(user.Email)
+	a.Decode(forgotPassword(a, user.Email), http.StatusOK, nil)
+	token := a.ResetToken(user.Email)
+	if token == first {
+		t.Fatal("no se envió un token nuevo")
+	}
+
+	// The new token replaces the old one
+	expectError(t, a, resetPassword(a, first, "nueva123"), http.StatusBadRequest, "invalid_reset_token")
+	expectError(t, a, resetPassword(a, token+"x", "nueva123"), http.StatusBadRequest, "invalid_reset_token")
+	a.Decode(resetPassword(a, token, "nueva123"), http.StatusOK, nil)
+
+	// A token works once
+	expectError(t, a, resetPassword(a, token, "otra1234"), http.StatusBadRequest, "invalid_reset_token")
+
+	// The sessions opened with the old password are closed
+	expectError(t, a, a.Request(http.MethodGet, "/api/v1/notes", user.AccessToken, nil), http.StatusUnauthorized, "session_revoked")
+	rec := a.Request(http.MethodPost, "/api/v1/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+	expectError(t, a, rec, http.StatusUnauthorized, "invalid_refresh_token")
+
+	// The lockout is lifted and only the new password works
+	expectError(t, a, login(a, user.Email, apptest.Password), http.StatusUnauthorized, "invalid_credentials")
+	a.Decode(login(a, user.Email, "nueva123"), http.StatusOK, nil)
+}
+
+func TestForgotPasswordRevealsNothing(t *testing.T) {
+	a := apptest.New(t)
+	user := a.SignUp("ana")
+	before := a.LastMail(user.Email)
+
+	// Deactivated and unknown accounts get the same answer and no email
+	ctx := context.Background()
+	if _, err := a.Container.UserService.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Status: "inactivo"}); err != nil {
+		t.Fatal(err)
+	}
+	inactive := forgotPassword(a, user.Email)
+	unknown := forgotPassword(a, "nadie@example.com")
+	a.Decode(inactive, http.StatusOK, nil)
+	if inactive.Body.String() != unknown.Body.String() {
+		t.Error("la respuesta revela qué emails están registrados")
+	}
+	if a.LastMail(user.Email) != before {
+		t.Error("se envió un token a una cuenta desactivada")
+	}
+}
+
+func TestResetPasswordVerifiesPendingAccounts(t *testing.T) {
+	a := apptest.New(t)
+	a.Decode(register(t, a, "/api/v1/auth/register", "ana"), http.StatusCreated, nil)
+	expectError(t, a, login(a, "ana@example.com", apptest.Password), http.StatusForbidden, "email_not_verified")
+
+	// The token proves the email belongs to the owner of the account
+	a.Decode(forgotPassword(a, "ana@example.com"), http.StatusOK, nil)
+	a.Decode(resetPassword(a, a.ResetToken("ana@example.com"), "nueva123"), http.StatusOK, nil)
+
+	var resp models.LoginResponse
+	a.Decode(login(a, "ana@example.com", "nueva123"), http.StatusOK, &resp)
+	if resp.User.Status != "activo" {
+		t.Errorf("estado %q tras restablecer la contraseña, se esperaba activo", resp.User.Status)
+	}
+}
+
+func TestLoginLimitIgnoresSpoofedForwarding(t *testing.T) {
+	rate := ratelimit.Rate{Limit: 2, Period: time.Minute}
+	loginFrom := func(a *apptest.App, forwardedFor string) *httptest.ResponseRecorder {
+		req := a.NewRequest(http.MethodPost, "/login", "", models.LoginRequest{Email: "nadie@example.com", Password: "incorrecta"})
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		return a.Do(req)
+	}
+
+	// An untrusted peer shares its bucket whatever the header says
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginIPRate = rate
+	})
+	for _, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+		expectError(t, a, loginFrom(a, ip), http.StatusUnauthorized, "invalid_credentials")
+	}
+	expectError(t, a, loginFrom(a, "198.51.100.3"), http.StatusTooManyRequests, "too_many_requests")
+
+	// Behind a trusted proxy each forwarded client has its own
+	t.Run("proxy", func(t *testing.T) {
+		a := apptest.New(t, func(cfg *config.Config) {
+			cfg.Auth.LoginIPRate = rate
+			cfg.Server.TrustedProxies = []string{"192.0.2.0/24"}
+		})
+		for _, ip := range []string{"198.51.100.1", "198.51.100.1", "198.51.100.2"} {
+			expectError(t, a, loginFrom(a, ip), http.StatusUnauthorized, "invalid_credentials")
+		}
+		expectError(t, a, loginFrom(a, "198.51.100.1"), http.StatusTooManyRequests, "too_many_requests")
+	})
+}
@@ -1,21 +1,120 @@
 package database
 
 import (
-	"notasGo/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"notasGo/config"
+	"notasGo/dialect"
+	"notasGo/logging"
+	"notasGo/migrations"
 
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// Connection is an open database together with the optional features it supports
+type Connection struct {
+	DB      *gorm.DB
+	Dialect dialect.Dialect
+	// SearchEnabled reports whether the full-text index is available.
+	// It is false when SQLite was built without FTS5 (missing -tags sqlite_fts5).
+	SearchEnabled bool
+}
+
+// Open opens the database described by cfg without touching its schema
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	d, err := dialect.For(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(d.Open(cfg.DSN), &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar a la base de datos: %w", err)
+	}
+	return db, nil
+}
+
+// Connect opens the database described by cfg and makes sure its schema is
+// up to date, applying the pending migrations when cfg.AutoMigrate is set.
+// With SQLite, tests can use the DSN "file::memory:?cache=shared" to work on
+// a throwaway database.
+func Connect(cfg config.DatabaseConfig) (*Connection, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareSchema(db, cfg.AutoMigrate); err != nil {
+		return nil, err
+	}
+
+	d, err := dialect.Of(db)
+	if err != nil {
+		return nil, err
+	}
 
-func Connect() {
-	db, err := gorm.Open(sqlite.Open("notas.db"), &gorm.Config{})
+	conn := &Connection{DB: db, Dialect: d}
+	if err := d.SetupSearch(db); err != nil {
+		slog.Warn("Búsqueda de texto completo deshabilitada", slog.Any("error", err))
+	} else {
+		conn.SearchEnabled = true
+	}
+
+	return conn, nil
+}
+
+// prepareSchema applies the pending migrations or, when autoMigrate is off,
+// fails if there are any
+func prepareSchema(db *gorm.DB, autoMigrate bool) error {
+	if !autoMigrate {
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("hay %d migraciones pendientes, ejecuta \"migrate up\"", len(pending))
+		}
+		return nil
+	}
+
+	applied, err := migrations.Up(db)
+	for _, m := range applied {
+		slog.Info("Migración aplicada", slog.Int("version", m.Version), slog.String("name", m.Name))
+	}
+	return err
+}
+
+// Ping checks that the database answers
+func (c *Connection) Ping(ctx context.Context) error {
+	sqlDB, err := c.DB.DB()
 	if err != nil {
-		panic("No se pudo conectar a la base de datos: " + err.Error())
+		return err
 	}
+	return sqlDB.PingContext(ctx)
+}
 
-	db.AutoMigrate(&models.Note{}, &models.User{})
+// MigrationStatus counts the applied and the pending migrations
+func (c *Connection) MigrationStatus(ctx context.Context) (applied int, pending int, err error) {
+	statuses, err := migrations.Statuses(c.DB.WithContext(ctx))
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		} else {
+			applied++
+		}
+	}
+	return applied, pending, nil
+}
 
-	DB = db
+// Close closes the connections to the database
+func (c *Connection) Close() error {
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,88 @@
+package dialect
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Dialect isolates what differs between the supported database engines:
+// how to connect, the full-text index of notes and case-insensitive unique
+// indexes. Everything else goes through GORM and portable SQL.
+type Dialect interface {
+	// Name is the driver name used in the configuration
+	Name() string
+	// Open returns the GORM dialector connecting to dsn
+	Open(dsn string) gorm.Dialector
+	// SetupSearch creates the full-text index of notes if it is missing.
+	// It returns an error when the engine cannot provide one.
+	SetupSearch(db *gorm.DB) error
+	// SearchNotes returns a query over the notes not in the trash that match
+	// every term. It selects id, title_snippet, snippet and score, where a
+	// lower score is a better match. The snippets are plain text with the
+	// matches between MatchStart and MatchEnd, see HighlightHTML. Callers add
+	// ownership, order and limit.
+	SearchNotes(db *gorm.DB, terms []string) *gorm.DB
+	// CreateLowerUniqueIndex creates a unique index over the lower case value
+	// of column, so values differing only in case are rejected
+	CreateLowerUniqueIndex(db *gorm.DB, table, name, column string) error
+}
+
+// MatchStart and MatchEnd delimit the matches in the snippets selected by
+// SearchNotes. They are control characters, so they are never confused with
+// markup written in the notes.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// HighlightHTML escapes a snippet selected by SearchNotes and wraps its
+// matches in <mark> tags. Markers that are not part of a start and end pair,
+// which can only come from the notes themselves, are dropped, so the tags
+// are always balanced.
+func HighlightHTML(snippet string) string {
+	var b strings.Builder
+	open := false
+	for {
+		i := strings.IndexAny(snippet, MatchStart+MatchEnd)
+		if i < 0 {
+			b.WriteString(html.EscapeString(snippet))
+			break
+		}
+		b.WriteString(html.EscapeString(snippet[:i]))
+		switch {
+		case snippet[i] == MatchStart[0] && !open:
+			next := strings.IndexAny(snippet[i+1:], MatchStart+MatchEnd)
+			if next >= 0 && snippet[i+1+next] == MatchEnd[0] {
+				b.WriteString("<mark>")
+				open = true
+			}
+		case snippet[i] == MatchEnd[0] && open:
+			b.WriteString("</mark>")
+			open = false
+		}
+		snippet = snippet[i+1:]
+	}
+	return b.String()
+}
+
+// For returns the dialect of the driver named in the configuration
+func For(driver string) (Dialect, error) {
+	switch driver {
+	case "sqlite":
+		return SQLite{}, nil
+	case "postgres":
+		return Postgres{}, nil
+	case "mysql":
+		return MySQL{}, nil
+	}
+	return nil, fmt.Errorf("driver %q no soportado, usa sqlite, postgres o mysql", driver)
+}
+
+// Of returns the dialect of an open database, for code such as migrations
+// that receives a *gorm.DB but not the configuration
+func Of(db *gorm.DB) (Dialect, error) {
+	return For(db.Dialector.Name())
+}
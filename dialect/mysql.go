@@ -0,0 +1,96 @@
+package dialect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// MySQL searches with an InnoDB FULLTEXT index. It has no highlighting
+// function, so the snippets are the title and the start of the content with
+// the terms marked by REGEXP_REPLACE. Requires MySQL 8.0.13 or later.
+type MySQL struct{}
+
+// mysqlSnippetLength is the number of characters of content in a snippet
+const mysqlSnippetLength = 160
+
+func (MySQL) Name() string {
+	return "mysql"
+}
+
+func (MySQL) Open(dsn string) gorm.Dialector {
+	return mysqlDialector{Dialector: mysql.Open(dsn).(*mysql.Dialector)}
+}
+
+// mysqlIndexedStringSize is the size of the indexed string columns that do
+// not set one, the longest utf8mb4 VARCHAR an index key can hold
+const mysqlIndexedStringSize = 191
+
+// mysqlDialector gives a size to the string columns of unique indexes that
+// do not set one. GORM only does it for the index and unique tags and would
+// create them as longtext, which MySQL cannot index.
+type mysqlDialector struct {
+	*mysql.Dialector
+}
+
+func (d mysqlDialector) DataTypeOf(field *schema.Field) string {
+	if field.DataType == schema.String && field.Size == 0 && field.TagSettings["UNIQUEINDEX"] != "" {
+		sized := *field
+		sized.Size = mysqlIndexedStringSize
+		return d.Dialector.DataTypeOf(&sized)
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+// Migrator returns the MySQL migrator resolving column types through d
+func (d mysqlDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	m := d.Dialector.Migrator(db).(mysql.Migrator)
+	m.Migrator.Config.Dialector = d
+	return m
+}
+
+func (MySQL) SetupSearch(db *gorm.DB) error {
+	if db.Migrator().HasIndex("notes", "idx_notes_search") {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX idx_notes_search ON notes (title, content)").Error
+}
+
+// SearchNotes requires every term in boolean mode, quoting them so operators
+// typed by the user are matched literally. Terms shorter than
+// innodb_ft_min_token_size or in the stopword list never match. The terms
+// are marked as whole words ignoring case, so unlike the index they are not
+// marked when they only match without accents.
+func (MySQL) SearchNotes(db *gorm.DB, terms []string) *gorm.DB {
+	required := make([]string, len(terms))
+	words := make([]string, len(terms))
+	for i, term := range terms {
+		term = strings.ReplaceAll(term, `"`, "")
+		required[i] = `+"` + term + `"`
+		words[i] = regexp.QuoteMeta(term)
+	}
+	against := strings.Join(required, " ")
+	pattern := `\b(` + strings.Join(words, "|") + `)\b`
+	replacement := MatchStart + "$1" + MatchEnd
+
+	return db.Table("notes").
+		Select(fmt.Sprintf(`notes.id AS id,
+			REGEXP_REPLACE(notes.title, ?, ?, 1, 0, 'i') AS title_snippet,
+			REGEXP_REPLACE(LEFT(coalesce(notes.content, ''), %d), ?, ?, 1, 0, 'i') AS snippet,
+			-MATCH(notes.title, notes.content) AGAINST (? IN BOOLEAN MODE) AS score`, mysqlSnippetLength),
+			pattern, replacement, pattern, replacement, against).
+		Where("MATCH(notes.title, notes.content) AGAINST (? IN BOOLEAN MODE) AND notes.deleted_at IS NULL", against)
+}
+
+// CreateLowerUniqueIndex uses a functional key part, hence the double
+// parentheses
+func (MySQL) CreateLowerUniqueIndex(db *gorm.DB, table, name, column string) error {
+	if db.Migrator().HasIndex(table, name) {
+		return nil
+	}
+	return db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s ((lower(%s)))", name, table, column)).Error
+}
@@ -0,0 +1,48 @@
+package dialect
+
+import (
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMySQLSizesUnsizedUniqueIndexes(t *testing.T) {
+	type row struct {
+		ID      uint
+		Hash    string `gorm:"uniqueIndex;not null"`
+		Code    string `gorm:"uniqueIndex;size:32"`
+		Email   string `gorm:"index"`
+		Content string
+	}
+
+	s, err := schema.Parse(&row{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The migrator resolves the types, no server is needed to build it
+	d := mysqlDialector{Dialector: mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(localhost:3306)/notas",
+		SkipInitializeWithVersion: true,
+	}).(*mysql.Dialector)}
+	db, err := gorm.Open(d, &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator := db.Migrator().(mysql.Migrator)
+
+	want := map[string]string{
+		"Hash":    "varchar(191)",
+		"Code":    "varchar(32)",
+		"Email":   "varchar(191)",
+		"Content": "longtext",
+	}
+	for name, dataType := range want {
+		if got := migrator.Migrator.DataTypeOf(s.LookUpField(name)); got != dataType {
+			t.Errorf("%s: tipo %q, se esperaba %q", name, got, dataType)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Postgres searches with a GIN index over the tsvector of the title and
+// content of notes, using the "simple" configuration so no language is
+// assumed
+type Postgres struct{}
+
+// postgresDocument is the text searched in each note. The index and the
+// queries must use the same expression for the index to be used.
+const postgresDocument = "to_tsvector('simple', coalesce(notes.title, '') || ' ' || coalesce(notes.content, ''))"
+
+func (Postgres) Name() string {
+	return "postgres"
+}
+
+func (Postgres) Open(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
+
+func (Postgres) SetupSearch(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_notes_search ON notes USING GIN (" + postgresDocument + ")").Error
+}
+
+// SearchNotes matches every term with plainto_tsquery, which ignores the
+// operators typed by the user, and ranks the notes with ts_rank
+func (Postgres) SearchNotes(db *gorm.DB, terms []string) *gorm.DB {
+	selectors := "StartSel=" + MatchStart + ", StopSel=" + MatchEnd
+
+	return db.Table("notes, plainto_tsquery('simple', ?) AS search_query", strings.Join(terms, " ")).
+		Select(`notes.id AS id,
+			ts_headline('simple', notes.title, search_query, ?) AS title_snippet,
+			ts_headline('simple', coalesce(notes.content, ''), search_query, ?) AS snippet,
+			-ts_rank(`+postgresDocument+`, search_query) AS score`,
+			selectors+", HighlightAll=true", selectors+", MinWords=8, MaxWords=16").
+		Where(postgresDocument + " @@ search_query AND notes.deleted_at IS NULL")
+}
+
+func (Postgres) CreateLowerUniqueIndex(db *gorm.DB, table, name, column string) error {
+	return db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (lower(%s))", name, table, column)).Error
+}
@@ -0,0 +1,96 @@
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLite searches with an FTS5 virtual table, which requires building with
+// -tags sqlite_fts5
+type SQLite struct{}
+
+func (SQLite) Name() string {
+	return "sqlite"
+}
+
+func (SQLite) Open(dsn string) gorm.Dialector {
+	return sqlite.Open(dsn)
+}
+
+// sqliteSearchStatements create the FTS5 index over notes and the triggers
+// that keep it in sync. Every write on the notes table goes through the
+// triggers, so the index never needs to be updated by hand.
+var sqliteSearchStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+		title,
+		content,
+		content='notes',
+		content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	)`,
+	`CREATE TRIGGER IF NOT EXISTS notes_fts_ai AFTER INSERT ON notes BEGIN
+		INSERT INTO notes_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS notes_fts_ad AFTER DELETE ON notes BEGIN
+		INSERT INTO notes_fts(notes_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS notes_fts_au AFTER UPDATE OF title, content ON notes BEGIN
+		INSERT INTO notes_fts(notes_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+		INSERT INTO notes_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+	END`,
+}
+
+// SetupSearch creates the FTS5 index. Databases created before the index
+// existed are backfilled with the notes they already hold.
+func (SQLite) SetupSearch(db *gorm.DB) error {
+	if !sqliteFTS5 {
+		return errors.New("SQLite se compiló sin FTS5, compila con -tags sqlite_fts5")
+	}
+
+	var existing int64
+	if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'notes_fts'").Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range sqliteSearchStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+
+		if existing == 0 {
+			return tx.Exec("INSERT INTO notes_fts(notes_fts) VALUES ('rebuild')").Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w (compila con -tags sqlite_fts5)", err)
+	}
+	return nil
+}
+
+// SearchNotes quotes every term, so operators and punctuation typed by the
+// user are matched literally, and ranks the notes with bm25
+func (SQLite) SearchNotes(db *gorm.DB, terms []string) *gorm.DB {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	return db.Table("notes_fts").
+		Select(`notes.id AS id,
+			highlight(notes_fts, 0, ?, ?) AS title_snippet,
+			snippet(notes_fts, 1, ?, ?, '…', 16) AS snippet,
+			bm25(notes_fts) AS score`, MatchStart, MatchEnd, MatchStart, MatchEnd).
+		Joins("JOIN notes ON notes.id = notes_fts.rowid AND notes.deleted_at IS NULL").
+		Where("notes_fts MATCH ?", strings.Join(quoted, " "))
+}
+
+func (SQLite) CreateLowerUniqueIndex(db *gorm.DB, table, name, column string) error {
+	return db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (lower(%s))", name, table, column)).Error
+}
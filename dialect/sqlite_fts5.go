@@ -0,0 +1,6 @@
+//go:build sqlite_fts5
+
+package dialect
+
+// sqliteFTS5 reports whether the SQLite driver was built with FTS5
+const sqliteFTS5 = true
@@ -0,0 +1,132 @@
+// Package i18n translates the messages returned by the API and shown in the
+// HTML templates. Each supported language has a JSON catalog embedded from
+// locales/ that maps stable message IDs to text. Texts may contain {name}
+// placeholders that are filled from Params.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLocale is used when the client does not ask for a supported language
+const DefaultLocale = "es"
+
+const localeKey = "locale"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs holds the messages of every supported locale by message ID
+var catalogs = loadCatalogs()
+
+// Params are the values substituted in the {name} placeholders of a message
+type Params map[string]string
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic("No se pudieron leer los catálogos de mensajes: " + err.Error())
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic("No se pudo leer el catálogo " + file.Name() + ": " + err.Error())
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("Catálogo de mensajes inválido " + file.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Translate returns the text of a message in the given locale. Messages
+// missing from the locale fall back to DefaultLocale, and unknown IDs are
+// returned unchanged.
+func Translate(locale string, id string, params Params) string {
+	text, ok := catalogs[locale][id]
+	if !ok {
+		if text, ok = catalogs[DefaultLocale][id]; !ok {
+			return id
+		}
+	}
+
+	for name, value := range params {
+		text = strings.ReplaceAll(text, "{"+name+"}", value)
+	}
+	return text
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language
+// header, honouring quality values and matching regional variants (en-US)
+// by their base language
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, options, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(options), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{tag: strings.ToLower(tag), quality: quality})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return DefaultLocale
+		}
+		base, _, _ := strings.Cut(c.tag, "-")
+		if _, ok := catalogs[base]; ok {
+			return base
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale stores the negotiated locale of the request
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(localeKey, locale)
+}
+
+// Locale returns the locale of the request, DefaultLocale if none was negotiated
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// T translates a message to the locale of the request
+func T(c *gin.Context, id string) string {
+	return Translate(Locale(c), id, nil)
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which a query is logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger sends the gorm logs to the logger of the context of each
+// query, so database errors carry the request ID of the request that caused
+// them. Failed queries are logged as errors, slow ones as warnings and the
+// rest at debug level. Queries are logged without their parameters, which
+// may hold passwords or tokens.
+type GormLogger struct{}
+
+// NewGormLogger returns the gorm logger of the application
+func NewGormLogger() GormLogger {
+	// Scan and Rows trace their SQL through a recorder that gorm filters
+	// with this package level hook instead of the logger
+	gormlogger.RecorderParamsFilter = GormLogger{}.ParamsFilter
+	return GormLogger{}
+}
+
+// LogMode is required by gorm; the level is decided by the slog handler
+func (l GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	logger := FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	level, msg := slog.LevelDebug, "Consulta ejecutada"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "Error en consulta"
+	case elapsed > slowQueryThreshold:
+		level, msg = slog.LevelWarn, "Consulta lenta"
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter leaves the parameters out of the logged SQL
+func (l GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,79 @@
+// Package mail sends the emails of the application through an SMTP server
+// or, for development and tests, writes them to an outbox directory.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	// Send delivers msg, or returns why it could not
+	Send(ctx context.Context, msg Message) error
+}
+
+// parseFrom checks the sender of the emails, such as
+// "NotasGo <no-reply@example.com>", and returns its bare address
+func parseFrom(from string) (string, error) {
+	address, err := netmail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("remitente inválido %q: %w", from, err)
+	}
+	return address.Address, nil
+}
+
+// format renders msg sent by from at now as an RFC 5322 message. The body
+// goes as 8bit UTF-8, so the links stay readable in the outbox; the emails
+// are short texts, well under the 998 characters a line may have.
+func format(from string, msg Message, now time.Time) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("cabecera inválida en el email para %q", msg.To)
+	}
+
+	sender, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("remitente inválido %q: %w", from, err)
+	}
+	domain := sender.Address[strings.LastIndex(sender.Address, "@")+1:]
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", sender.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", id, domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
+
+// randomID returns a random hexadecimal identifier
+func randomID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error al generar identificador del email: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
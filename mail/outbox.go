@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutboxMailer writes each email to a .eml file in a directory instead of
+// sending it, for development and tests. The files open in any mail client
+// and the links they carry can be followed by hand or by a test.
+type OutboxMailer struct {
+	dir  string
+	from string
+}
+
+// NewOutboxMailer returns a mailer writing the emails sent as from to dir,
+// which is created if missing
+func NewOutboxMailer(dir string, from string) (*OutboxMailer, error) {
+	if _, err := parseFrom(from); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de salida del correo: %w", err)
+	}
+	return &OutboxMailer{dir: dir, from: from}, nil
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := format(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	// Names sort by sending time, the random part keeps them unique
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), id[:8])
+
+	// The emails carry tokens, so only the owner of the process reads them
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("no se pudo escribir el email: %w", err)
+	}
+	return nil
+}
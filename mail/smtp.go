@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// sendTimeout bounds the conversation with the SMTP server when the context
+// of the email has no deadline
+const sendTimeout = 30 * time.Second
+
+// SMTPMailer sends the emails through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it
+type SMTPMailer struct {
+	addr     string
+	host     string
+	from     string
+	sender   string
+	username string
+	password string
+}
+
+// NewSMTPMailer returns a mailer sending as from through the server at addr
+// (host:port). The username and password, when set, authenticate with
+// PLAIN, which net/smtp only allows over TLS or to localhost.
+func NewSMTPMailer(addr string, username string, password string, from string) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("dirección SMTP inválida %q: %w", addr, err)
+	}
+	sender, err := parseFrom(from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SMTPMailer{
+		addr:     addr,
+		host:     host,
+		from:     from,
+		sender:   sender,
+		username: username,
+		password: password,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("no se pudo conectar al servidor SMTP: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sendTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("no se pudo conectar al servidor SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("error al iniciar TLS con el servidor SMTP: %w", err)
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("error al autenticar con el servidor SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.sender); err != nil {
+		return fmt.Errorf("remitente rechazado por el servidor SMTP: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("destinatario rechazado por el servidor SMTP: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error al enviar el email: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error al enviar el email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error al enviar el email: %w", err)
+	}
+	return client.Quit()
+}
@@ -1,12 +1,29 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notasGo/app"
+	"notasGo/config"
 	"notasGo/database"
+	"notasGo/logging"
 	"notasGo/routes"
+	"notasGo/tracing"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "notasGo/docs" // documentación generada por swag
 )
 
+// readHeaderTimeout limits how long a client may take to send the request
+// headers, so idle connections cannot hold the server
+const readHeaderTimeout = 10 * time.Second
+
 // @title NotasGo API
 // @version 2.0
 // @description API REST refactorizada para gestión de notas y usuarios con Go, Gin y GORM
@@ -17,20 +34,126 @@ import (
 // @contact.email soporte@notasgo.com
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Token de acceso con el formato "Bearer {token}"
 
 func main() {
+	// Cargar y validar la configuración
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Configuración inválida:")
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  -", problem)
+		}
+		os.Exit(1)
+	}
+
+	// Logs estructurados; el paquete log también pasa por slog
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level))
+
+	// Subcomandos
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" {
+			fmt.Fprintf(os.Stderr, "Comando desconocido %q. %s\n", os.Args[1], migrateUsage)
+			os.Exit(2)
+		}
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Trazas de OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("No se pudo configurar el trazado", err)
+	}
+
 	// Inicializar la base de datos
-	database.Connect()
+	conn, err := database.Connect(cfg.Database)
+	if err != nil {
+		fatal("No se pudo preparar la base de datos", err)
+	}
+
+	// Construir repositorios y servicios
+	container, err := app.NewContainer(cfg, conn)
+	if err != nil {
+		fatal("No se pudo construir la aplicación", err)
+	}
+
+	// SIGINT o SIGTERM inician el apagado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Inicializar Gin con rutas
-	r := routes.SetupRouter()
+	// Vaciar periódicamente la papelera
+	purgerDone := container.TrashService.StartPurger(ctx)
 
-	// Servir archivos estáticos (CSS, imágenes, JS, etc.)
-	r.Static("/static", "./static")
+	// Inicializar Gin con rutas, templates y archivos estáticos
+	r := routes.SetupRouter(container)
 
-	// Cargar templates HTML
-	r.LoadHTMLGlob("templates/*")
+	// Iniciar servidor
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Servidor escuchando", slog.String("addr", cfg.Server.Addr))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Métricas de Prometheus en su propio listener, fuera de la API pública
+	var metricsServer *http.Server
+	if cfg.Server.MetricsAddr != config.MetricsOff {
+		metricsServer = &http.Server{
+			Addr:              cfg.Server.MetricsAddr,
+			Handler:           routes.SetupMetricsRouter(container),
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		go func() {
+			slog.Info("Métricas escuchando", slog.String("addr", cfg.Server.MetricsAddr))
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		fatal("El servidor se detuvo", err)
+	case <-ctx.Done():
+	}
+
+	// Una segunda señal termina el proceso sin esperar
+	stop()
+	slog.Info("Apagando el servidor", slog.Duration("timeout", cfg.Server.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Las peticiones en curso no terminaron a tiempo", slog.Any("error", err))
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+
+	<-purgerDone
+	if err := container.Close(); err != nil {
+		slog.Error("Error al cerrar las conexiones de la aplicación", slog.Any("error", err))
+	}
+	if err := conn.Close(); err != nil {
+		slog.Error("Error al cerrar la base de datos", slog.Any("error", err))
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("No se pudieron enviar las trazas pendientes", slog.Any("error", err))
+	}
+	slog.Info("Servidor detenido")
+}
 
-	// Iniciar servidor en el puerto 8080
-	r.Run(":8080")
+// fatal logs err and ends the process
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
 }
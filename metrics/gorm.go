@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startKey stores the start time of an operation in its gorm statement
+const startKey = "metrics:start"
+
+// callbackPrefix names the callbacks registered by the plugin
+const callbackPrefix = "metrics:"
+
+// GORMPlugin returns a gorm plugin that records the duration and errors of
+// every operation run on the database it is used on:
+//
+//	db.Use(m.GORMPlugin())
+func (m *Metrics) GORMPlugin() gorm.Plugin {
+	return &gormPlugin{metrics: m}
+}
+
+type gormPlugin struct {
+	metrics *Metrics
+}
+
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize wraps each kind of operation with a callback before and after
+// the gorm ones
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("*").Register, callbacks.Create().After("*").Register},
+		{"query", callbacks.Query().Before("*").Register, callbacks.Query().After("*").Register},
+		{"update", callbacks.Update().Before("*").Register, callbacks.Update().After("*").Register},
+		{"delete", callbacks.Delete().Before("*").Register, callbacks.Delete().After("*").Register},
+		{"row", callbacks.Row().Before("*").Register, callbacks.Row().After("*").Register},
+		{"raw", callbacks.Raw().Before("*").Register, callbacks.Raw().After("*").Register},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before(callbackPrefix+"before_"+processor.operation, startTimer); err != nil {
+			return err
+		}
+		if err := processor.after(callbackPrefix+"after_"+processor.operation, p.observe(processor.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+// observe returns the callback recording an operation once gorm ran it
+func (p *gormPlugin) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.metrics.dbDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+
+		// A lookup that finds nothing is an expected outcome, not a failure
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.metrics.dbErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveRequestCollapsesUnknownMethods(t *testing.T) {
+	m := New()
+
+	for _, method := range []string{http.MethodGet, "FOO", "BAR", "get"} {
+		m.ObserveRequest(method, "/api/v1/notes", http.StatusOK, time.Millisecond)
+	}
+
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/api/v1/notes", "2xx")); got != 1 {
+		t.Errorf("%v peticiones GET, se esperaba 1", got)
+	}
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues("OTHER", "/api/v1/notes", "2xx")); got != 3 {
+		t.Errorf("%v peticiones OTHER, se esperaban 3", got)
+	}
+	if got := testutil.CollectAndCount(m.httpRequests); got != 2 {
+		t.Errorf("%d series, se esperaban 2", got)
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"notasGo/services"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// statsTTL is how long the business totals are reused between scrapes, so
+// frequent scrapes do not query the database each time
+const statsTTL = 30 * time.Second
+
+// statsTimeout bounds the refresh of the totals, so a stuck database cannot
+// hold the scrape and the collector lock
+const statsTimeout = 5 * time.Second
+
+// StatsSource computes the business totals exported as gauges
+type StatsSource interface {
+	Stats(ctx context.Context) (services.Stats, error)
+}
+
+// RegisterStats exports the totals of source as gauges, computed on scrape
+// and cached for statsTTL
+func (m *Metrics) RegisterStats(source StatsSource) {
+	m.registry.MustRegister(&statsCollector{
+		source:       source,
+		notes:        prometheus.NewDesc(namespace+"_notes", "Notas fuera de la papelera.", nil, nil),
+		trashedNotes: prometheus.NewDesc(namespace+"_notes_trashed", "Notas en la papelera.", nil, nil),
+		users:        prometheus.NewDesc(namespace+"_users", "Usuarios fuera de la papelera.", nil, nil),
+		activeUsers:  prometheus.NewDesc(namespace+"_users_active", "Usuarios con estado activo.", nil, nil),
+	})
+}
+
+type statsCollector struct {
+	source StatsSource
+
+	notes        *prometheus.Desc
+	trashedNotes *prometheus.Desc
+	users        *prometheus.Desc
+	activeUsers  *prometheus.Desc
+
+	mu        sync.Mutex
+	stats     services.Stats
+	fetchedAt time.Time
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.notes
+	ch <- c.trashedNotes
+	ch <- c.users
+	ch <- c.activeUsers
+}
+
+// Collect reports the cached totals, refreshing them once they expire. If
+// the refresh fails the gauges are left out of the scrape.
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) > statsTTL {
+		ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+		stats, err := c.source.Stats(ctx)
+		cancel()
+		if err != nil {
+			slog.Error("Error al calcular las métricas de negocio", slog.Any("error", err))
+			return
+		}
+		c.stats, c.fetchedAt = stats, time.Now()
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.notes, prometheus.GaugeValue, float64(c.stats.Notes))
+	ch <- prometheus.MustNewConstMetric(c.trashedNotes, prometheus.GaugeValue, float64(c.stats.TrashedNotes))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(c.stats.Users))
+	ch <- prometheus.MustNewConstMetric(c.activeUsers, prometheus.GaugeValue, float64(c.stats.ActiveUsers))
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"notasGo/logging"
+	"notasGo/models"
+	"notasGo/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AccessTokenCookie is the cookie used by the HTML dashboard to carry the access token
+	AccessTokenCookie = "access_token"
+
+	authUserKey = "authUser"
+)
+
+// RequireAuth validates the bearer token of the request and stores the
+// authenticated user in the context. Requests without a valid token, or
+// whose session has been revoked, are rejected with 401 by ErrorHandler.
+func RequireAuth(tokenService *services.TokenService, sessionService *services.SessionService, userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := extractToken(c)
+		if tokenString == "" {
+			abortWithError(c, services.ErrTokenRequired)
+			return
+		}
+
+		claims, err := tokenService.ParseAccessToken(tokenString)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		ctx := c.Request.Context()
+		active, err := sessionService.IsSessionActive(ctx, claims.SessionID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !active {
+			abortWithError(c, services.ErrSessionRevoked)
+			return
+		}
+
+		user, err := userService.GetUserByID(ctx, claims.UserID())
+		if err != nil {
+			if errors.Is(err, services.ErrUserNotFound) {
+				err = services.ErrInvalidToken
+			}
+			abortWithError(c, err)
+			return
+		}
+
+		if user.Status != "activo" {
+			abortWithError(c, services.ErrAccountInactive)
+			return
+		}
+
+		user.Password = ""
+		c.Set(authUserKey, user)
+
+		// Later logs of the request, including database errors, name the user
+		logger := logging.FromContext(ctx).With(slog.Uint64("user_id", uint64(user.ID)))
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, logger))
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated by RequireAuth
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	value, exists := c.Get(authUserKey)
+	if !exists {
+		return nil, false
+	}
+	user, ok := value.(*models.User)
+	return user, ok
+}
+
+// extractToken reads the token from the Authorization header, falling back
+// to the dashboard cookie
+func extractToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	if cookie, err := c.Cookie(AccessTokenCookie); err == nil {
+		return cookie
+	}
+	return ""
+}
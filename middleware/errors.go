@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"notasGo/i18n"
+	"notasGo/logging"
+	"notasGo/models"
+	"notasGo/services"
+	"notasGo/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusByKind maps each kind of domain error to its HTTP status
+var statusByKind = map[services.ErrorKind]int{
+	services.KindValidation:           http.StatusBadRequest,
+	services.KindUnauthorized:         http.StatusUnauthorized,
+	services.KindInactive:             http.StatusForbidden,
+	services.KindForbidden:            http.StatusForbidden,
+	services.KindNotFound:             http.StatusNotFound,
+	services.KindConflict:             http.StatusConflict,
+	services.KindPreconditionFailed:   http.StatusPreconditionFailed,
+	services.KindPreconditionRequired: http.StatusPreconditionRequired,
+	services.KindUnavailable:          http.StatusServiceUnavailable,
+	services.KindTooManyRequests:      http.StatusTooManyRequests,
+}
+
+// ErrorHandler writes the error response for the last error attached to the
+// context with c.Error. Domain errors from the services keep their code and
+// message, and binding errors they wrap are expanded into field details;
+// anything else is logged and reported as a generic 500.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var domainErr *services.Error
+		if errors.As(err, &domainErr) {
+			status := ErrorStatus(domainErr)
+			if domainErr.RetryAfter > 0 {
+				c.Header("Retry-After", retryAfterSeconds(domainErr.RetryAfter))
+			}
+
+			locale := i18n.Locale(c)
+			fields, details := translateFields(domainErr.Fields, locale), domainErr.Err
+			if len(fields) == 0 {
+				if fields = utils.ValidationFields(domainErr.Err, locale); len(fields) > 0 {
+					details = nil
+				}
+			}
+
+			utils.ErrorResponse(c, status, domainErr.Code, domainErr.Message(), fields, details)
+			return
+		}
+
+		logging.FromContext(c.Request.Context()).Error("Error interno", slog.Any("error", err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal_error", "error.internal_error", nil, nil)
+	}
+}
+
+// ErrorStatus returns the HTTP status of a domain error, 500 when its kind
+// has none
+func ErrorStatus(err *services.Error) int {
+	if status, ok := statusByKind[err.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// translateFields resolves the message IDs of the field details set by the services
+func translateFields(fields []models.FieldError, locale string) []models.FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	translated := make([]models.FieldError, len(fields))
+	for i, field := range fields {
+		field.Message = i18n.Translate(locale, field.Message, i18n.Params{
+			"field": field.Field,
+			"param": field.Param,
+		})
+		translated[i] = field
+	}
+	return translated
+}
+
+// retryAfterSeconds formats d for the Retry-After header, rounded up so the
+// client never retries too early
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// abortWithError stops the handler chain and leaves err for ErrorHandler
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
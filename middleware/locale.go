@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"notasGo/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale negotiates the language of the response from the Accept-Language
+// header and stores it for the handlers, utils responses and templates
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		i18n.SetLocale(c, locale)
+
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
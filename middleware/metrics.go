@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"notasGo/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels the requests that match no route, so scanners
+// probing random paths do not create a series per path
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and duration of every request under the
+// template of the route it matched
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"notasGo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch rejects writes that do not send an If-Match header with 428,
+// forcing clients to prove they are editing the latest version of a resource
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("If-Match") == "" {
+			abortWithError(c, services.ErrIfMatchRequired)
+			return
+		}
+		c.Next()
+	}
+}
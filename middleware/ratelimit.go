@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"notasGo/logging"
+	"notasGo/ratelimit"
+	"notasGo/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit rejects with 429 and Retry-After the requests of a client that
+// used up its bucket in limiter. key identifies the client of a request.
+// Every response carries the RateLimit-Policy, RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers.
+//
+// If the store fails, for example because Redis is down, the request goes
+// through: an outage of the limiter must not take the API down with it.
+func RateLimit(limiter *ratelimit.Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	if !limiter.Enabled() {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	rate := limiter.Rate()
+	policy := fmt.Sprintf("%d;w=%d", rate.Limit, int64(rate.Period.Seconds()))
+
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), key(c))
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("Límite de peticiones no disponible", slog.Any("error", err))
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Policy", policy)
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", retryAfterSeconds(result.Reset))
+
+		if !result.Allowed {
+			abortWithError(c, services.ErrTooManyRequests.WithRetryAfter(result.RetryAfter))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIPKey identifies the client of a request by its IP. Gin only reads
+// it from the forwarding headers when the connection comes from one of the
+// proxies in TRUSTED_PROXIES, so clients cannot pick their own bucket.
+func ClientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// UserKey identifies the client of a request by the authenticated user, so
+// the limit follows the account across IPs, or by its IP before RequireAuth
+func UserKey(c *gin.Context) string {
+	if user, ok := CurrentUser(c); ok {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	return ClientIPKey(c)
+}
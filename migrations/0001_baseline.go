@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// baseline creates the schema as it was before versioned migrations, when
+// it came from AutoMigrate. The tables are frozen copies of the models of
+// that time, so later changes to the models do not alter this migration.
+// Databases created by AutoMigrate already match it and are adopted as is.
+//
+// The full-text index of notes is not part of the migrations because it
+// depends on SQLite being built with FTS5; database.Connect sets it up.
+var baseline = Migration{
+	Version: 1,
+	Name:    "baseline",
+	Up: func(tx *gorm.DB) error {
+		type User struct {
+			ID        uint   `gorm:"primaryKey;autoIncrement"`
+			Username  string `gorm:"unique;not null"`
+			Email     string `gorm:"unique;not null"`
+			Password  string `gorm:"not null"`
+			Role      string `gorm:"default:user"`
+			Status    string `gorm:"default:activo"`
+			CreatedAt time.Time
+			UpdatedAt time.Time
+			DeletedAt gorm.DeletedAt `gorm:"index"`
+		}
+
+		type Tag struct {
+			ID        uint   `gorm:"primaryKey;autoIncrement"`
+			Name      string `gorm:"uniqueIndex;not null;size:50"`
+			CreatedAt time.Time
+		}
+
+		type Note struct {
+			ID        int    `gorm:"primaryKey;autoIncrement"`
+			Title     string `gorm:"not null"`
+			Content   string
+			UserID    uint  `gorm:"default:1"`
+			User      User  `gorm:"foreignKey:UserID"`
+			Tags      []Tag `gorm:"many2many:note_tags"`
+			Version   int   `gorm:"not null;default:1"`
+			CreatedAt time.Time
+			UpdatedAt time.Time
+			DeletedAt gorm.DeletedAt `gorm:"index"`
+		}
+
+		type Session struct {
+			ID        uint      `gorm:"primaryKey;autoIncrement"`
+			UserID    uint      `gorm:"index;not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null"`
+			ExpiresAt time.Time `gorm:"not null"`
+			RevokedAt *time.Time
+			CreatedAt time.Time
+			UpdatedAt time.Time
+		}
+
+		type NoteRevision struct {
+			ID        uint   `gorm:"primaryKey;autoIncrement"`
+			NoteID    int    `gorm:"not null;uniqueIndex:idx_note_revision_number"`
+			Number    int    `gorm:"not null;uniqueIndex:idx_note_revision_number"`
+			Title     string `gorm:"not null"`
+			Content   string
+			AuthorID  uint `gorm:"not null"`
+			CreatedAt time.Time
+		}
+
+		return tx.AutoMigrate(&Note{}, &User{}, &Session{}, &Tag{}, &NoteRevision{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("notes_fts", "note_tags", "note_revisions", "sessions", "notes", "tags", "users")
+	},
+}
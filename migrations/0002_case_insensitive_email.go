@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"notasGo/dialect"
+
+	"gorm.io/gorm"
+)
+
+// caseInsensitiveEmail makes the email of users unique regardless of case,
+// so "Ana@example.com" and "ana@example.com" cannot be two accounts. It
+// fails if the database already holds such duplicates; merge or rename them
+// and run it again.
+var caseInsensitiveEmail = Migration{
+	Version: 2,
+	Name:    "case_insensitive_email",
+	Up: func(tx *gorm.DB) error {
+		d, err := dialect.Of(tx)
+		if err != nil {
+			return err
+		}
+		return d.CreateLowerUniqueIndex(tx, "users", "idx_users_email_lower", "email")
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropIndex("users", "idx_users_email_lower")
+	},
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// loginLockouts adds the table counting the failed logins per email
+var loginLockouts = Migration{
+	Version: 3,
+	Name:    "login_lockouts",
+	Up: func(tx *gorm.DB) error {
+		type LoginLockout struct {
+			ID            uint      `gorm:"primaryKey;autoIncrement"`
+			Email         string    `gorm:"uniqueIndex;not null;size:255"`
+			Failures      int       `gorm:"not null;default:0"`
+			LastFailureAt time.Time `gorm:"index;not null"`
+			LockedUntil   *time.Time
+			CreatedAt     time.Time
+			UpdatedAt     time.Time
+		}
+
+		return tx.AutoMigrate(&LoginLockout{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("login_lockouts")
+	},
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userTokens adds the table of the single-use tokens mailed to users
+var userTokens = Migration{
+	Version: 4,
+	Name:    "user_tokens",
+	Up: func(tx *gorm.DB) error {
+		type UserToken struct {
+			ID        uint      `gorm:"primaryKey;autoIncrement"`
+			UserID    uint      `gorm:"index;not null"`
+			Purpose   string    `gorm:"not null;size:32"`
+			TokenHash string    `gorm:"uniqueIndex;not null;size:64"`
+			ExpiresAt time.Time `gorm:"index;not null"`
+			UsedAt    *time.Time
+			CreatedAt time.Time
+		}
+
+		return tx.AutoMigrate(&UserToken{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("user_tokens")
+	},
+}
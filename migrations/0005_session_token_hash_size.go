@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionTokenHashSize limits the token hash of sessions to the 64
+// characters of a hex SHA-256, as user_tokens does. SQLite ignores the size
+// of a column, so it is left as is there.
+var sessionTokenHashSize = Migration{
+	Version: 5,
+	Name:    "session_token_hash_size",
+	Up: func(tx *gorm.DB) error {
+		type Session struct {
+			ID        uint      `gorm:"primaryKey;autoIncrement"`
+			UserID    uint      `gorm:"index;not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null;size:64"`
+			ExpiresAt time.Time `gorm:"not null"`
+			RevokedAt *time.Time
+			CreatedAt time.Time
+			UpdatedAt time.Time
+		}
+
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&Session{}, "TokenHash")
+	},
+	Down: func(tx *gorm.DB) error {
+		type Session struct {
+			ID        uint      `gorm:"primaryKey;autoIncrement"`
+			UserID    uint      `gorm:"index;not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null"`
+			ExpiresAt time.Time `gorm:"not null"`
+			RevokedAt *time.Time
+			CreatedAt time.Time
+			UpdatedAt time.Time
+		}
+
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&Session{}, "TokenHash")
+	},
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// LoginLockout counts the failed logins in a row against an email, whether
+// or not an account uses it, and how long further attempts are refused
+type LoginLockout struct {
+	ID            uint       `json:"-" gorm:"primaryKey;autoIncrement"`
+	Email         string     `json:"email" gorm:"uniqueIndex;not null;size:255"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"index;not null"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// IsLocked reports whether logins are refused at now
+func (l *LoginLockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
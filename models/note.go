@@ -1,16 +1,28 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxNoteContentLength is the longest content a note may have, in
+// characters. It bounds the revisions and the diffs between them; the
+// binding tags of the note requests repeat it.
+const MaxNoteContentLength = 100000
 
 // Note define la estructura mínima de una nota
 type Note struct {
-	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Title     string    `json:"title" gorm:"not null"`
-	Content   string    `json:"content"`
-	UserID    uint      `json:"user_id" gorm:"default:1"`
-	User      User      `json:"user" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title     string         `json:"title" gorm:"not null"`
+	Content   string         `json:"content"`
+	UserID    uint           `json:"user_id" gorm:"default:1"`
+	User      User           `json:"user" gorm:"foreignKey:UserID"`
+	Tags      []Tag          `json:"tags" gorm:"many2many:note_tags"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type Mensaje struct {
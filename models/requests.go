@@ -19,15 +19,64 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"password123"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"3q2-7wQ1n0Yk..."`
+}
+
+type VerifyEmailQuery struct {
+	Token string `form:"token" binding:"required" example:"Xk1o9-Qw3hT..."`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"Xk1o9-Qw3hT..."`
+	Password string `json:"password" binding:"required,min=6" example:"newpassword123"`
+}
+
+// List query structures
+type PageQuery struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1" example:"20"`
+	Cursor string `form:"cursor" example:"eyJzIjoiY3JlYXRlZF9hdCIsIm8iOiJkZXNjIn0"`
+	Page   int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc" example:"desc"`
+}
+
+type NoteListQuery struct {
+	PageQuery
+	Sort    string   `form:"sort" binding:"omitempty,oneof=created_at updated_at title" example:"created_at"`
+	UserID  uint     `form:"user_id" example:"1"`
+	Tags    []string `form:"tag" binding:"omitempty,max=20,dive,min=1,max=50" example:"trabajo"`
+	TagMode string   `form:"tag_mode" binding:"omitempty,oneof=any all" example:"any"`
+}
+
+type UserListQuery struct {
+	PageQuery
+	Sort   string `form:"sort" binding:"omitempty,oneof=created_at updated_at username" example:"created_at"`
+	Role   string `form:"role" binding:"omitempty,oneof=user admin" example:"user"`
+	Status string `form:"status" binding:"omitempty,oneof=activo inactivo pendiente" example:"activo"`
+}
+
+type NoteSearchQuery struct {
+	Q     string `form:"q" binding:"required,min=1,max=200" example:"reunión proyecto"`
+	Limit int    `form:"limit" binding:"omitempty,min=1" example:"20"`
+}
+
 // Note request structures
 type CreateNoteRequest struct {
-	Title   string `json:"title" binding:"required,min=1,max=200" example:"Mi nota importante"`
-	Content string `json:"content" binding:"required" example:"Esta es el contenido de mi nota"`
-	UserID  uint   `json:"user_id" binding:"required" example:"1"`
+	Title   string   `json:"title" binding:"required,min=1,max=200" example:"Mi nota importante"`
+	Content string   `json:"content" binding:"required,max=100000" example:"Esta es el contenido de mi nota"`
+	Tags    []string `json:"tags,omitempty" binding:"omitempty,max=20,dive,min=1,max=50" example:"trabajo,ideas"`
 }
 
 type UpdateNoteRequest struct {
-	Title   string `json:"title,omitempty" binding:"omitempty,min=1,max=200" example:"Nota actualizada"`
-	Content string `json:"content,omitempty" example:"Contenido actualizado"`
-	UserID  uint   `json:"user_id,omitempty" example:"1"`
-}
\ No newline at end of file
+	Title   string   `json:"title,omitempty" binding:"omitempty,min=1,max=200" example:"Nota actualizada"`
+	Content string   `json:"content,omitempty" binding:"omitempty,max=100000" example:"Contenido actualizado"`
+	Tags    []string `json:"tags,omitempty" binding:"omitempty,max=20,dive,min=1,max=50" example:"trabajo"`
+}
@@ -10,9 +10,19 @@ type APIResponse struct {
 }
 
 type ErrorResponse struct {
-	Success bool   `json:"success" example:"false"`
-	Message string `json:"message" example:"Error en la operación"`
-	Error   string `json:"error,omitempty" example:"Detalles del error"`
+	Success bool         `json:"success" example:"false"`
+	Code    string       `json:"code" example:"note_not_found"`
+	Message string       `json:"message" example:"Error en la operación"`
+	Error   string       `json:"error,omitempty" example:"Detalles del error"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Rule    string `json:"rule" example:"max"`
+	Param   string `json:"param,omitempty" example:"200"`
+	Message string `json:"message" example:"El campo title debe tener como máximo 200 caracteres"`
 }
 
 // User responses
@@ -26,17 +36,40 @@ type UserResponse struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// LockoutResponse is the login lockout of an account
+type LockoutResponse struct {
+	Email         string     `json:"email" example:"john@example.com"`
+	Failures      int        `json:"failures" example:"4"`
+	Locked        bool       `json:"locked" example:"true"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+}
+
 type LoginResponse struct {
-	Success bool         `json:"success" example:"true"`
-	Message string       `json:"message" example:"Login exitoso"`
-	User    UserResponse `json:"user"`
+	Success      bool         `json:"success" example:"true"`
+	Message      string       `json:"message" example:"Login exitoso"`
+	AccessToken  string       `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string       `json:"refresh_token" example:"3q2-7wQ1n0Yk..."`
+	TokenType    string       `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64        `json:"expires_in" example:"900"`
+	User         UserResponse `json:"user"`
+}
+
+// PageInfo describes the position of a page inside a list
+type PageInfo struct {
+	Limit      int    `json:"limit" example:"20"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCIsIm8iOiJkZXNjIn0"`
 }
 
 type UsersListResponse struct {
-	Success bool           `json:"success" example:"true"`
-	Message string         `json:"message" example:"Usuarios obtenidos exitosamente"`
-	Users   []UserResponse `json:"users"`
-	Total   int64          `json:"total" example:"10"`
+	Success    bool           `json:"success" example:"true"`
+	Message    string         `json:"message" example:"Usuarios obtenidos exitosamente"`
+	Users      []UserResponse `json:"users"`
+	Total      int64          `json:"total" example:"10"`
+	Limit      int            `json:"limit" example:"20"`
+	HasMore    bool           `json:"has_more" example:"true"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCIsIm8iOiJkZXNjIn0"`
 }
 
 // Note responses
@@ -46,21 +79,126 @@ type NoteResponse struct {
 	Content   string       `json:"content" example:"Contenido de la nota"`
 	UserID    uint         `json:"user_id" example:"1"`
 	User      UserResponse `json:"user"`
+	Tags      []string     `json:"tags" example:"trabajo,ideas"`
+	Version   int          `json:"version" example:"3"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
 }
 
 type NotesListResponse struct {
-	Success bool           `json:"success" example:"true"`
-	Message string         `json:"message" example:"Notas obtenidas exitosamente"`
-	Notes   []NoteResponse `json:"notes"`
-	Total   int64          `json:"total" example:"5"`
+	Success    bool           `json:"success" example:"true"`
+	Message    string         `json:"message" example:"Notas obtenidas exitosamente"`
+	Notes      []NoteResponse `json:"notes"`
+	Total      int64          `json:"total" example:"5"`
+	Limit      int            `json:"limit" example:"20"`
+	HasMore    bool           `json:"has_more" example:"false"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCIsIm8iOiJkZXNjIn0"`
 }
 
 type UserNotesResponse struct {
-	Success  bool           `json:"success" example:"true"`
-	Message  string         `json:"message" example:"Notas del usuario obtenidas exitosamente"`
-	User     UserResponse   `json:"user"`
-	Notes    []NoteResponse `json:"notes"`
-	Total    int64          `json:"total" example:"3"`
-}
\ No newline at end of file
+	Success    bool           `json:"success" example:"true"`
+	Message    string         `json:"message" example:"Notas del usuario obtenidas exitosamente"`
+	User       UserResponse   `json:"user"`
+	Notes      []NoteResponse `json:"notes"`
+	Total      int64          `json:"total" example:"3"`
+	Limit      int            `json:"limit" example:"20"`
+	HasMore    bool           `json:"has_more" example:"false"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCIsIm8iOiJkZXNjIn0"`
+}
+
+// Search responses
+type NoteSearchResult struct {
+	Note         NoteResponse `json:"note"`
+	TitleSnippet string       `json:"title_snippet" example:"Acta de la <mark>reunión</mark>"`
+	Snippet      string       `json:"snippet" example:"…se acordó en la <mark>reunión</mark> del <mark>proyecto</mark>…"`
+	Rank         float64      `json:"rank" example:"-2.31"`
+}
+
+type NoteSearchResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Message string             `json:"message" example:"Búsqueda realizada exitosamente"`
+	Query   string             `json:"query" example:"reunión proyecto"`
+	Results []NoteSearchResult `json:"results"`
+	Total   int                `json:"total" example:"2"`
+}
+
+// Tag responses
+type TagResponse struct {
+	Name  string `json:"name" example:"trabajo"`
+	Count int64  `json:"count" example:"4"`
+}
+
+type TagsListResponse struct {
+	Success bool          `json:"success" example:"true"`
+	Message string        `json:"message" example:"Etiquetas obtenidas exitosamente"`
+	Tags    []TagResponse `json:"tags"`
+	Total   int           `json:"total" example:"3"`
+}
+
+// Revision responses
+type RevisionResponse struct {
+	NoteID    int       `json:"note_id" example:"1"`
+	Number    int       `json:"number" example:"3"`
+	Title     string    `json:"title" example:"Mi nota"`
+	Content   string    `json:"content" example:"Contenido anterior de la nota"`
+	AuthorID  uint      `json:"author_id" example:"1"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevisionsListResponse struct {
+	Success   bool               `json:"success" example:"true"`
+	Message   string             `json:"message" example:"Revisiones obtenidas exitosamente"`
+	Revisions []RevisionResponse `json:"revisions"`
+	Total     int                `json:"total" example:"3"`
+}
+
+type RevisionDiffResponse struct {
+	Success   bool   `json:"success" example:"true"`
+	Message   string `json:"message" example:"Diferencias obtenidas exitosamente"`
+	From      string `json:"from" example:"1"`
+	To        string `json:"to" example:"current"`
+	FromTitle string `json:"from_title" example:"Mi nota"`
+	ToTitle   string `json:"to_title" example:"Mi nota editada"`
+	Diff      string `json:"diff" example:"--- revisión 1\n+++ actual\n@@ -1,1 +1,1 @@\n-antes\n+después\n"`
+}
+
+type TrashedNoteResponse struct {
+	ID        int       `json:"id" example:"1"`
+	Title     string    `json:"title" example:"Mi nota"`
+	UserID    uint      `json:"user_id" example:"1"`
+	Tags      []string  `json:"tags" example:"trabajo,ideas"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashedUserResponse struct {
+	ID        uint      `json:"id" example:"2"`
+	Username  string    `json:"username" example:"johndoe"`
+	Email     string    `json:"email" example:"john@example.com"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Message string                `json:"message" example:"Papelera obtenida exitosamente"`
+	Notes   []TrashedNoteResponse `json:"notes"`
+	Users   []TrashedUserResponse `json:"users"`
+}
+
+// Health responses
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+type ReadinessResponse struct {
+	Status     string          `json:"status" example:"ready"`
+	Database   string          `json:"database" example:"ok"`
+	Migrations MigrationsCheck `json:"migrations"`
+}
+
+type MigrationsCheck struct {
+	Status  string `json:"status" example:"ok"`
+	Applied int    `json:"applied" example:"2"`
+	Pending int    `json:"pending" example:"0"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// NoteRevision keeps the title and content a note had before an update.
+// AuthorID is the user whose edit replaced that content.
+type NoteRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	NoteID    int       `json:"note_id" gorm:"not null;uniqueIndex:idx_note_revision_number"`
+	Number    int       `json:"number" gorm:"not null;uniqueIndex:idx_note_revision_number"`
+	Title     string    `json:"title" gorm:"not null"`
+	Content   string    `json:"content"`
+	AuthorID  uint      `json:"author_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
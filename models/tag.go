@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tag labels notes by topic. Names are stored lowercase and are shared by all users.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null;size:50"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+// Package policy centralizes the role based authorization rules of the API.
+// Admins can manage every account and note, regular users only their own.
+package policy
+
+import "notasGo/models"
+
+// CanListUsers reports whether the actor can list every registered user
+func CanListUsers(actor *models.User) bool {
+	return actor.IsAdmin()
+}
+
+// CanViewUser reports whether the actor can read the profile of the target user
+func CanViewUser(actor *models.User, targetID uint) bool {
+	return actor.IsAdmin() || actor.ID == targetID
+}
+
+// CanUpdateUser reports whether the actor can apply the request to the target user.
+// Users can only edit their own profile and never their role or status.
+func CanUpdateUser(actor *models.User, targetID uint, req *models.UpdateUserRequest) bool {
+	if actor.IsAdmin() {
+		return true
+	}
+	if actor.ID != targetID {
+		return false
+	}
+	return req.Role == "" && req.Status == ""
+}
+
+// CanDeleteUser reports whether the actor can delete the target account
+func CanDeleteUser(actor *models.User, targetID uint) bool {
+	return actor.IsAdmin() || actor.ID == targetID
+}
+
+// CanRestoreUser reports whether the actor can bring a deleted account back from the trash
+func CanRestoreUser(actor *models.User) bool {
+	return actor.IsAdmin()
+}
+
+// CanManageLockouts reports whether the actor can see and clear the login lockout of any account
+func CanManageLockouts(actor *models.User) bool {
+	return actor.IsAdmin()
+}
+
+// CanViewAllNotes reports whether the actor can see notes owned by anyone
+func CanViewAllNotes(actor *models.User) bool {
+	return actor.IsAdmin()
+}
+
+// CanViewUserNotes reports whether the actor can list the notes of the target user
+func CanViewUserNotes(actor *models.User, targetID uint) bool {
+	return CanViewAllNotes(actor) || actor.ID == targetID
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result is the outcome of taking a request from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of requests left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, zero when
+	// the request was allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the token buckets of the limiters
+type Store interface {
+	// Take takes a request from the bucket of key, which holds rate.Limit
+	// requests and earns one back every rate.Period/rate.Limit
+	Take(ctx context.Context, key string, rate Rate) (Result, error)
+	// Reset forgets the requests taken from the bucket of key
+	Reset(ctx context.Context, key string) error
+	// Close releases the connections of the store
+	Close() error
+}
+
+// Limiter allows rate to each client, identified by a key, with its buckets
+// kept in a store shared with other limiters under its own name
+type Limiter struct {
+	store Store
+	name  string
+	rate  Rate
+}
+
+// NewLimiter returns a limiter named name allowing rate to each client. A
+// zero rate disables it.
+func NewLimiter(store Store, name string, rate Rate) *Limiter {
+	return &Limiter{store: store, name: name, rate: rate}
+}
+
+// Enabled reports whether the limiter limits anything
+func (l *Limiter) Enabled() bool {
+	return l.rate.Limit > 0
+}
+
+// Rate returns the rate allowed to each client
+func (l *Limiter) Rate() Rate {
+	return l.rate
+}
+
+// Allow takes a request from the bucket of the client key
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	if !l.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, l.name+":"+key, l.rate)
+}
+
+// Reset forgets the requests made by the client key
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, l.name+":"+key)
+}
+
+// newResult describes a bucket left with tokens after a request was allowed
+// or refused
+func newResult(rate Rate, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rate.Limit,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(rate.Limit) - tokens) * float64(rate.interval())),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(rate.interval()))
+	}
+	return result
+}
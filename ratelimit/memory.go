@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store drops the full buckets
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in the memory of the process, so each
+// instance limits on its own and the limits start over on restart. Buckets
+// that filled up again are dropped, as they behave like new ones, so the
+// memory used is bounded by the clients seen recently.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket is the state of a key: the tokens it had at updated
+type bucket struct {
+	rate    Rate
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Limit), updated: now}
+		s.buckets[key] = b
+	}
+	b.rate = rate
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(rate, b.tokens, allowed), nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets, key)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// refill adds the tokens earned since the last update, up to the limit
+func (b *bucket) refill(now time.Time) {
+	earned := float64(now.Sub(b.updated)) / float64(b.rate.interval())
+	b.tokens = min(b.tokens+earned, float64(b.rate.Limit))
+	b.updated = now
+}
+
+// sweep drops the buckets that are full again, at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.rate.Limit) {
+			delete(s.buckets, key)
+		}
+	}
+}
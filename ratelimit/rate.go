@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Off is the rate that disables a limit
+const Off = "off"
+
+// periods maps the units accepted by ParseRate to their duration
+var periods = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// Rate is a number of requests allowed per period. A client may spend the
+// whole Limit at once, then gets one more request every Period/Limit.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate reads a rate written as "<limit>/<unit>", with s, m or h as the
+// unit, such as "10/m". "off" or an empty value is the zero rate, which
+// disables the limit.
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == Off {
+		return Rate{}, nil
+	}
+	limit, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("tasa inválida %q, usa valores como \"10/m\" u \"off\"", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("tasa inválida %q, el límite debe ser un entero positivo", value)
+	}
+	period, ok := periods[strings.TrimSpace(unit)]
+	if !ok {
+		return Rate{}, fmt.Errorf("tasa inválida %q, la unidad debe ser s, m o h", value)
+	}
+	return Rate{Limit: n, Period: period}, nil
+}
+
+// interval is the time it takes to earn back one request, at least a
+// microsecond, the resolution of the Redis store
+func (r Rate) interval() time.Duration {
+	return max(r.Period/time.Duration(r.Limit), time.Microsecond)
+}
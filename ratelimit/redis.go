@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces the buckets in a Redis shared with other applications
+const redisKeyPrefix = "notasgo:ratelimit:"
+
+// takeScript refills and takes from a bucket stored as a hash in a single
+// step, so concurrent instances cannot both spend the last token. Times are
+// in microseconds. The key expires once the bucket would be full again.
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or limit
+local updated = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - updated) / interval)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.max(1, math.ceil((limit - tokens) * interval / 1000)))
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps the buckets in Redis, so every instance of the API
+// shares the same limits. The clocks of the instances are used to refill
+// the buckets and should be kept in sync.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore returns a store on client. Any client works, including one
+// connected to an in-process stand-in such as miniredis.
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// OpenRedisStore connects to the Redis at url, such as
+// "redis://localhost:6379/0"
+func OpenRedisStore(url string) (*RedisStore, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("URL de Redis inválida: %w", err)
+	}
+	return NewRedisStore(redis.NewClient(options)), nil
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{redisKeyPrefix + key},
+		rate.Limit, rate.interval().Microseconds(), time.Now().UnixMicro()).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("error al consultar el límite en Redis: %w", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("respuesta inesperada de Redis: %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("respuesta inesperada de Redis: %v", reply)
+	}
+	return newResult(rate, tokens, allowed == 1), nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisKeyPrefix+key).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	t.Cleanup(func() { store.Close() })
+	return store, server
+}
+
+func TestRedisStoreSpendsTheBucket(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	rate := Rate{Limit: 3, Period: time.Minute}
+
+	for i := 1; i <= rate.Limit; i++ {
+		result, err := store.Take(ctx, "client", rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("petición %d rechazada con el bucket sin agotar", i)
+		}
+		if result.Limit != rate.Limit || result.Remaining != rate.Limit-i {
+			t.Errorf("petición %d: límite %d y restantes %d, se esperaba %d y %d", i, result.Limit, result.Remaining, rate.Limit, rate.Limit-i)
+		}
+	}
+
+	result, err := store.Take(ctx, "client", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Fatal("petición permitida con el bucket agotado")
+	}
+	// One request is earned back every 20 s
+	if result.RetryAfter <= 0 || result.RetryAfter > 20*time.Second {
+		t.Errorf("Retry-After de %s, se esperaba hasta 20s", result.RetryAfter)
+	}
+	if result.Reset <= 40*time.Second || result.Reset > time.Minute {
+		t.Errorf("reset de %s, se esperaba casi un minuto", result.Reset)
+	}
+
+	// Each client has its own bucket
+	result, err = store.Take(ctx, "other", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Error("el bucket de otro cliente se agotó con el primero")
+	}
+}
+
+func TestRedisStoreRefillsTheBucket(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	// One request is earned back every 50 ms
+	rate := Rate{Limit: 20, Period: time.Second}
+
+	for range rate.Limit {
+		if _, err := store.Take(ctx, "client", rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := store.Take(ctx, "client", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Fatal("petición permitida con el bucket agotado")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	result, err = store.Take(ctx, "client", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Error("el bucket no recuperó la petición tras el intervalo")
+	}
+}
+
+func TestRedisStoreExpiresFullBuckets(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	ctx := context.Background()
+	rate := Rate{Limit: 2, Period: time.Minute}
+
+	if _, err := store.Take(ctx, "client", rate); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key lives until the bucket would be full again, 30 s for one token
+	key := redisKeyPrefix + "client"
+	ttl := server.TTL(key)
+	if ttl <= 0 || ttl > 30*time.Second {
+		t.Fatalf("la clave caduca en %s, se esperaba hasta 30s", ttl)
+	}
+	server.FastForward(ttl)
+	if server.Exists(key) {
+		t.Error("la clave sigue en Redis con el bucket ya lleno")
+	}
+}
+
+func TestRedisStoreTakesAtomically(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	rate := Rate{Limit: 10, Period: time.Hour}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 5 * rate.Limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := store.Take(ctx, "client", rate)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != rate.Limit {
+		t.Errorf("%d peticiones concurrentes permitidas, se esperaban %d", allowed, rate.Limit)
+	}
+}
+
+func TestRedisStoreReset(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	rate := Rate{Limit: 1, Period: time.Hour}
+
+	if _, err := store.Take(ctx, "client", rate); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reset(ctx, "client"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := store.Take(ctx, "client", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Error("el bucket sigue agotado tras Reset")
+	}
+}
+
+func TestRedisStoreReportsErrors(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	server.Close()
+
+	if _, err := store.Take(context.Background(), "client", Rate{Limit: 1, Period: time.Hour}); err == nil {
+		t.Error("Take no devolvió error con Redis caído")
+	}
+}
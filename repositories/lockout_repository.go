@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockoutRepository stores the failed logins per email
+type LockoutRepository interface {
+	// Find loads the failures of an email, ErrNotFound if it has none
+	Find(ctx context.Context, email string) (*models.LoginLockout, error)
+	// RecordFailure counts a failed login at now and returns the updated
+	// row. Failures before since are forgotten, so the count starts over.
+	RecordFailure(ctx context.Context, email string, now time.Time, since time.Time) (*models.LoginLockout, error)
+	// Lock refuses the logins of an email until the given time
+	Lock(ctx context.Context, email string, until time.Time) error
+	// Delete forgets the failures of an email
+	Delete(ctx context.Context, email string) error
+	// DeleteStale forgets the emails whose last failure is before the given time
+	DeleteStale(ctx context.Context, before time.Time) error
+}
+
+type lockoutRepository struct {
+	db *gorm.DB
+}
+
+// NewLockoutRepository returns a LockoutRepository backed by db
+func NewLockoutRepository(db *gorm.DB) LockoutRepository {
+	return &lockoutRepository{db: db}
+}
+
+func (r *lockoutRepository) Find(ctx context.Context, email string) (*models.LoginLockout, error) {
+	var lockout models.LoginLockout
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&lockout).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &lockout, nil
+}
+
+func (r *lockoutRepository) RecordFailure(ctx context.Context, email string, now time.Time, since time.Time) (*models.LoginLockout, error) {
+	var lockout models.LoginLockout
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row may be created by a concurrent failure, so a conflict just
+		// means there is one to update
+		created := models.LoginLockout{Email: email, LastFailureAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+			return err
+		}
+
+		// A single UPDATE so concurrent failures are all counted. gorm sorts
+		// the columns, so failures is computed before last_failure_at
+		// changes even on MySQL, which applies the assignments in order.
+		err := tx.Model(&models.LoginLockout{}).Where("email = ?", email).Updates(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", since),
+			"last_failure_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("email = ?", email).First(&lockout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, email string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.LoginLockout{}).
+		Where("email = ?", email).
+		Update("locked_until", until).Error
+}
+
+func (r *lockoutRepository) Delete(ctx context.Context, email string) error {
+	return r.db.WithContext(ctx).Where("email = ?", email).Delete(&models.LoginLockout{}).Error
+}
+
+func (r *lockoutRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("last_failure_at < ?", before).Delete(&models.LoginLockout{}).Error
+}
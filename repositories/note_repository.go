@@ -0,0 +1,381 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/dialect"
+	"notasGo/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NoteFilter restricts the notes returned by NoteRepository.List
+type NoteFilter struct {
+	// OwnerID limits the list to the notes of the caller, 0 lists every note
+	OwnerID uint
+	// UserID limits the list to the notes of a user chosen by the client
+	UserID uint
+	Tags   []string
+	// TagMode "all" requires every tag, otherwise any of them is enough
+	TagMode string
+}
+
+// NoteUpdate describes a write to an existing note
+type NoteUpdate struct {
+	// Columns holds the new column values
+	Columns map[string]interface{}
+	// Tags replaces the tags of the note, nil leaves them untouched
+	Tags []string
+	// Revision, when set, is stored as the next revision of the note
+	Revision *models.NoteRevision
+}
+
+// TagUsage is a tag with the number of notes that use it
+type TagUsage struct {
+	Name  string
+	Count int64
+}
+
+// SearchHit is a note matched by a full-text search with its fragments as
+// escaped HTML, the matches wrapped in <mark> tags
+type SearchHit struct {
+	Note         models.Note
+	TitleSnippet string
+	Snippet      string
+	Rank         float64
+}
+
+// NoteRepository stores notes together with their tags and revisions.
+// ownerID arguments restrict lookups to the notes of that user, 0 means no
+// restriction. Lookups that find nothing return ErrNotFound.
+type NoteRepository interface {
+	// List counts the notes matched by filter and loads a page of them with user and tags
+	List(ctx context.Context, filter NoteFilter, page Page) ([]models.Note, int64, error)
+	// Count counts the notes in the trash if trashed is set, otherwise the
+	// notes out of it
+	Count(ctx context.Context, trashed bool) (int64, error)
+	// FindAll loads every note of the owner
+	FindAll(ctx context.Context, ownerID uint) ([]models.Note, error)
+	// FindByID loads a note with user and tags
+	FindByID(ctx context.Context, id uint, ownerID uint) (*models.Note, error)
+	// FindDeleted loads a note that is in the trash
+	FindDeleted(ctx context.Context, id uint, ownerID uint) (*models.Note, error)
+	// FindDeletedByOwner lists the notes in the trash, most recently deleted first
+	FindDeletedByOwner(ctx context.Context, ownerID uint) ([]models.Note, error)
+	// Create stores a new note with the given tags, creating the missing ones
+	Create(ctx context.Context, note *models.Note, tags []string) error
+	// Update applies update if the note still has the version that was read
+	// and bumps it. It returns ErrStaleVersion otherwise.
+	Update(ctx context.Context, note *models.Note, update NoteUpdate) error
+	// Delete moves a note to the trash. A non zero version must match.
+	Delete(ctx context.Context, id int, version int) error
+	// Restore takes a note out of the trash
+	Restore(ctx context.Context, note *models.Note) error
+	// SearchEnabled reports whether the full-text index is available
+	SearchEnabled() bool
+	// Search returns the notes matching every term, best match first
+	Search(ctx context.Context, terms []string, ownerID uint, limit int) ([]SearchHit, error)
+	// Revisions lists the revisions of a note, newest first
+	Revisions(ctx context.Context, noteID int) ([]models.NoteRevision, error)
+	// FindRevision loads a single revision of a note
+	FindRevision(ctx context.Context, noteID int, number int) (*models.NoteRevision, error)
+	// TagUsage lists the tags of the owner's notes with their usage counts
+	TagUsage(ctx context.Context, ownerID uint) ([]TagUsage, error)
+}
+
+type noteRepository struct {
+	db            *gorm.DB
+	dialect       dialect.Dialect
+	searchEnabled bool
+}
+
+// NewNoteRepository returns a NoteRepository backed by db, whose engine is
+// described by d. searchEnabled tells whether db holds the full-text index
+// of notes.
+func NewNoteRepository(db *gorm.DB, d dialect.Dialect, searchEnabled bool) NoteRepository {
+	return &noteRepository{db: db, dialect: d, searchEnabled: searchEnabled}
+}
+
+// scopeToOwner restricts a notes query to the notes of ownerID, if any
+func scopeToOwner(db *gorm.DB, ownerID uint) *gorm.DB {
+	if ownerID == 0 {
+		return db
+	}
+	return db.Where("notes.user_id = ?", ownerID)
+}
+
+func (r *noteRepository) List(ctx context.Context, filter NoteFilter, page Page) ([]models.Note, int64, error) {
+	query := scopeToOwner(r.db.WithContext(ctx).Model(&models.Note{}), filter.OwnerID)
+	if filter.UserID != 0 {
+		query = query.Where("notes.user_id = ?", filter.UserID)
+	}
+	query = r.filterByTags(query, filter.Tags, filter.TagMode)
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notes []models.Note
+	paged := applyPage(query.Session(&gorm.Session{}).Preload("User").Preload("Tags"), "notes", page)
+	if err := paged.Find(&notes).Error; err != nil {
+		return nil, 0, err
+	}
+	return notes, count, nil
+}
+
+func (r *noteRepository) Count(ctx context.Context, trashed bool) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Note{})
+	if trashed {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// filterByTags restricts a notes query to the notes labelled with the given tags
+func (r *noteRepository) filterByTags(db *gorm.DB, names []string, mode string) *gorm.DB {
+	names = normalizeTags(names)
+	if len(names) == 0 {
+		return db
+	}
+
+	tagged := r.db.Table("note_tags").
+		Select("note_tags.note_id").
+		Joins("JOIN tags ON tags.id = note_tags.tag_id").
+		Where("tags.name IN ?", names)
+	if mode == "all" {
+		tagged = tagged.Group("note_tags.note_id").Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	}
+
+	return db.Where("notes.id IN (?)", tagged)
+}
+
+func (r *noteRepository) FindAll(ctx context.Context, ownerID uint) ([]models.Note, error) {
+	var notes []models.Note
+	if err := scopeToOwner(r.db.WithContext(ctx), ownerID).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *noteRepository) FindByID(ctx context.Context, id uint, ownerID uint) (*models.Note, error) {
+	var note models.Note
+	if err := scopeToOwner(r.db.WithContext(ctx).Preload("User").Preload("Tags"), ownerID).Where("notes.id = ?", id).First(&note).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &note, nil
+}
+
+func (r *noteRepository) FindDeleted(ctx context.Context, id uint, ownerID uint) (*models.Note, error) {
+	var note models.Note
+	query := scopeToOwner(r.db.WithContext(ctx).Unscoped(), ownerID).Where("notes.deleted_at IS NOT NULL")
+	if err := query.Where("notes.id = ?", id).First(&note).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &note, nil
+}
+
+func (r *noteRepository) FindDeletedByOwner(ctx context.Context, ownerID uint) ([]models.Note, error) {
+	var notes []models.Note
+	query := scopeToOwner(r.db.WithContext(ctx).Unscoped().Model(&models.Note{}), ownerID).
+		Where("notes.deleted_at IS NOT NULL").
+		Preload("Tags").
+		Order("notes.deleted_at DESC")
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *noteRepository) Create(ctx context.Context, note *models.Note, tags []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		resolved, err := resolveTags(tx, tags)
+		if err != nil {
+			return err
+		}
+		note.Tags = resolved
+		return tx.Create(note).Error
+	})
+}
+
+func (r *noteRepository) Update(ctx context.Context, note *models.Note, update NoteUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if update.Revision != nil {
+			if err := createRevision(tx, update.Revision); err != nil {
+				return err
+			}
+		}
+
+		columns := map[string]interface{}{"version": gorm.Expr("version + 1")}
+		for field, value := range update.Columns {
+			columns[field] = value
+		}
+
+		result := tx.Model(&models.Note{}).Where("id = ? AND version = ?", note.ID, note.Version).Updates(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStaleVersion
+		}
+
+		if update.Tags == nil {
+			return nil
+		}
+
+		resolved, err := resolveTags(tx, update.Tags)
+		if err != nil {
+			return err
+		}
+		return tx.Model(note).Association("Tags").Replace(resolved)
+	})
+}
+
+func (r *noteRepository) Delete(ctx context.Context, id int, version int) error {
+	query := r.db.WithContext(ctx).Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Delete(&models.Note{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *noteRepository) Restore(ctx context.Context, note *models.Note) error {
+	return r.db.WithContext(ctx).Unscoped().Model(note).Update("deleted_at", nil).Error
+}
+
+func (r *noteRepository) SearchEnabled() bool {
+	return r.searchEnabled
+}
+
+// searchRow is a note matched by a search with its fragments and score
+type searchRow struct {
+	ID           int
+	TitleSnippet string
+	Snippet      string
+	Score        float64
+}
+
+func (r *noteRepository) Search(ctx context.Context, terms []string, ownerID uint, limit int) ([]SearchHit, error) {
+	search := r.dialect.SearchNotes(r.db.WithContext(ctx), terms)
+	search = scopeToOwner(search, ownerID).Order("score").Order("notes.id").Limit(limit)
+
+	var rows []searchRow
+	if err := search.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	ids := make([]int, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	var notes []models.Note
+	if err := r.db.WithContext(ctx).Preload("User").Preload("Tags").Where("id IN ?", ids).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		note, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			Note:         note,
+			TitleSnippet: dialect.HighlightHTML(row.TitleSnippet),
+			Snippet:      dialect.HighlightHTML(row.Snippet),
+			Rank:         row.Score,
+		})
+	}
+	return hits, nil
+}
+
+func (r *noteRepository) Revisions(ctx context.Context, noteID int) ([]models.NoteRevision, error) {
+	var revisions []models.NoteRevision
+	if err := r.db.WithContext(ctx).Where("note_id = ?", noteID).Order("number DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *noteRepository) FindRevision(ctx context.Context, noteID int, number int) (*models.NoteRevision, error) {
+	var revision models.NoteRevision
+	if err := r.db.WithContext(ctx).Where("note_id = ? AND number = ?", noteID, number).First(&revision).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &revision, nil
+}
+
+func (r *noteRepository) TagUsage(ctx context.Context, ownerID uint) ([]TagUsage, error) {
+	query := r.db.WithContext(ctx).Table("tags").
+		Select("tags.name AS name, COUNT(notes.id) AS count").
+		Joins("JOIN note_tags ON note_tags.tag_id = tags.id").
+		Joins("JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL")
+	query = scopeToOwner(query, ownerID).
+		Group("tags.id, tags.name").
+		Order("count DESC, tags.name ASC")
+
+	var usages []TagUsage
+	if err := query.Scan(&usages).Error; err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// createRevision stores revision numbered after the last revision of its note
+func createRevision(tx *gorm.DB, revision *models.NoteRevision) error {
+	var last int
+	if err := tx.Model(&models.NoteRevision{}).Where("note_id = ?", revision.NoteID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	revision.Number = last + 1
+	return tx.Create(revision).Error
+}
+
+// normalizeTags lowercases, trims and deduplicates tag names, keeping their order
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// resolveTags returns the tags with the given names, creating the missing ones
+func resolveTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range normalizeTags(names) {
+		tag := models.Tag{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
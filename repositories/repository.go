@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("registro no encontrado")
+	// ErrStaleVersion is returned when a conditional write finds that the row
+	// no longer has the version that was read
+	ErrStaleVersion = errors.New("la versión del registro ha cambiado")
+)
+
+// Page selects a slice of a sorted list. When After is set only the rows
+// following that key are returned (keyset pagination), otherwise the first
+// Offset rows are skipped.
+type Page struct {
+	Sort   string
+	Order  string
+	Limit  int
+	Offset int
+	After  *PageKey
+}
+
+// PageKey is the sort value and ID of the last row of the previous page
+type PageKey struct {
+	Value interface{}
+	ID    int64
+}
+
+// applyPage adds the ordering, keyset condition or offset, and limit of page
+// to a query over table. Rows with the same sort value are ordered by ID.
+func applyPage(db *gorm.DB, table string, page Page) *gorm.DB {
+	column := table + "." + page.Sort
+	idColumn := table + ".id"
+
+	if page.After != nil {
+		op := ">"
+		if page.Order == "desc" {
+			op = "<"
+		}
+		db = db.Where("("+column+" "+op+" ?) OR ("+column+" = ? AND "+idColumn+" "+op+" ?)",
+			page.After.Value, page.After.Value, page.After.ID)
+	}
+
+	db = db.Order(column + " " + page.Order).Order(idColumn + " " + page.Order)
+	if page.Offset > 0 {
+		db = db.Offset(page.Offset)
+	}
+	return db.Limit(page.Limit)
+}
+
+// notFound converts gorm.ErrRecordNotFound into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
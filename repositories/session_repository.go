@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository stores the login sessions backing refresh tokens
+type SessionRepository interface {
+	// Create stores a new session
+	Create(ctx context.Context, session *models.Session) error
+	// FindByID loads a session, ErrNotFound if it does not exist
+	FindByID(ctx context.Context, id uint) (*models.Session, error)
+	// FindByTokenHash loads the session of a refresh token, ErrNotFound if none
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	// Rotate replaces the token of a session that is still unrevoked and
+	// holds the token that was read. It returns ErrStaleVersion otherwise.
+	Rotate(ctx context.Context, session *models.Session, tokenHash string, expiresAt time.Time) error
+	// RevokeByUser revokes every active session of the user
+	RevokeByUser(ctx context.Context, userID uint, revokedAt time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository returns a SessionRepository backed by db
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uint) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Rotate(ctx context.Context, session *models.Session, tokenHash string, expiresAt time.Time) error {
+	// Conditional update so two concurrent refreshes cannot both succeed
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND token_hash = ? AND revoked_at IS NULL", session.ID, session.TokenHash).
+		Updates(map[string]interface{}{
+			"token_hash": tokenHash,
+			"expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeByUser(ctx context.Context, userID uint, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
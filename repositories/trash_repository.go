@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeResult counts the rows permanently removed by a purge
+type PurgeResult struct {
+	Notes int64
+	Users int64
+}
+
+// TrashRepository permanently removes what stayed in the trash too long
+type TrashRepository interface {
+	// Purge removes the notes and users deleted before cutoff, together with
+	// their revisions, tag links, sessions and mailed tokens. Notes of purged users go with
+	// them even if they were deleted later.
+	Purge(ctx context.Context, cutoff time.Time) (PurgeResult, error)
+}
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+// NewTrashRepository returns a TrashRepository backed by db
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+func (r *trashRepository) Purge(ctx context.Context, cutoff time.Time) (PurgeResult, error) {
+	var result PurgeResult
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		if err := tx.Unscoped().Model(&models.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+
+		expired := tx.Unscoped().Model(&models.Note{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		if len(userIDs) > 0 {
+			expired = expired.Or("user_id IN ?", userIDs)
+		}
+
+		var noteIDs []int
+		if err := expired.Pluck("id", &noteIDs).Error; err != nil {
+			return err
+		}
+
+		if len(noteIDs) > 0 {
+			if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN ?", noteIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&models.NoteRevision{}).Error; err != nil {
+				return err
+			}
+			deleted := tx.Unscoped().Where("id IN ?", noteIDs).Delete(&models.Note{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.Notes = deleted.RowsAffected
+		}
+
+		if len(userIDs) > 0 {
+			if err := tx.Where("user_id IN ?", userIDs).Delete(&models.Session{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id IN ?", userIDs).Delete(&models.UserToken{}).Error; err != nil {
+				return err
+			}
+			deleted := tx.Unscoped().Where("id IN ?", userIDs).Delete(&models.User{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.Users = deleted.RowsAffected
+		}
+
+		return nil
+	})
+
+	return result, err
+}
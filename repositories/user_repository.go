@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserFilter restricts the users returned by UserRepository.List
+type UserFilter struct {
+	Role   string
+	Status string
+}
+
+// UserRepository stores user accounts. Lookups skip the accounts in the
+// trash unless stated otherwise and return ErrNotFound when nothing matches.
+type UserRepository interface {
+	// List counts the users matched by filter and loads a page of them
+	List(ctx context.Context, filter UserFilter, page Page) ([]models.User, int64, error)
+	// Count counts the users matched by filter
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	// FindByID loads a user
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	// FindByEmail loads the user registered with email, ignoring case
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// FindDeleted loads a user that is in the trash
+	FindDeleted(ctx context.Context, id uint) (*models.User, error)
+	// FindDeletedAll lists the users in the trash, most recently deleted first
+	FindDeletedAll(ctx context.Context) ([]models.User, error)
+	// EmailTaken reports whether another account, even one in the trash, uses
+	// email in any case.
+	// exceptID excludes an account from the check, 0 checks them all.
+	EmailTaken(ctx context.Context, email string, exceptID uint) (bool, error)
+	// UsernameTaken reports whether another account, even one in the trash, uses username
+	UsernameTaken(ctx context.Context, username string, exceptID uint) (bool, error)
+	// Create stores a new user
+	Create(ctx context.Context, user *models.User) error
+	// Update changes the given columns of the user
+	Update(ctx context.Context, user *models.User, updates map[string]interface{}) error
+	// Delete moves the user and all their notes to the trash at deletedAt
+	Delete(ctx context.Context, user *models.User, deletedAt time.Time) error
+	// Restore takes the user out of the trash along with the notes deleted
+	// with the account
+	Restore(ctx context.Context, user *models.User) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository returns a UserRepository backed by db
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+// filterUsers restricts a users query to the users matched by filter
+func filterUsers(db *gorm.DB, filter UserFilter) *gorm.DB {
+	if filter.Role != "" {
+		db = db.Where("users.role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		db = db.Where("users.status = ?", filter.Status)
+	}
+	return db
+}
+
+func (r *userRepository) List(ctx context.Context, filter UserFilter, page Page) ([]models.User, int64, error) {
+	query := filterUsers(r.db.WithContext(ctx).Model(&models.User{}), filter)
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := applyPage(query.Session(&gorm.Session{}), "users", page).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, count, nil
+}
+
+func (r *userRepository) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	var count int64
+	err := filterUsers(r.db.WithContext(ctx).Model(&models.User{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("users.id = ?", id).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("lower(email) = lower(?)", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindDeleted(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Unscoped().Where("users.id = ? AND deleted_at IS NOT NULL", id).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindDeletedAll(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) EmailTaken(ctx context.Context, email string, exceptID uint) (bool, error) {
+	return r.taken(ctx, "lower(email) = lower(?)", email, exceptID)
+}
+
+func (r *userRepository) UsernameTaken(ctx context.Context, username string, exceptID uint) (bool, error) {
+	return r.taken(ctx, "username = ?", username, exceptID)
+}
+
+// taken reports whether an account other than exceptID matches condition
+func (r *userRepository) taken(ctx context.Context, condition string, value string, exceptID uint) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where(condition, value)
+	if exceptID != 0 {
+		query = query.Where("id != ?", exceptID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(user).Updates(updates).Error
+}
+
+func (r *userRepository) Delete(ctx context.Context, user *models.User, deletedAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Note{}).Where("user_id = ?", user.ID).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Update("deleted_at", deletedAt).Error
+	})
+}
+
+func (r *userRepository) Restore(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Note{}).
+			Where("user_id = ? AND deleted_at = ?", user.ID, user.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(user).Update("deleted_at", nil).Error
+	})
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"notasGo/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTokenRepository stores the single-use tokens mailed to users
+type UserTokenRepository interface {
+	// Replace stores a new token, deleting the other tokens of the user for
+	// the same purpose so only the last one mailed works
+	Replace(ctx context.Context, token *models.UserToken) error
+	// FindByHash loads the token with the given purpose and hash, ErrNotFound if none
+	FindByHash(ctx context.Context, purpose string, tokenHash string) (*models.UserToken, error)
+	// Use marks an unused token as used at usedAt and applies updates to its
+	// user in the same transaction. It returns ErrStaleVersion if the token
+	// was already used.
+	Use(ctx context.Context, token *models.UserToken, usedAt time.Time, updates map[string]interface{}) error
+	// DeleteByUser removes every token of the user, whatever its purpose
+	DeleteByUser(ctx context.Context, userID uint) error
+	// DeleteExpired removes the tokens that expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewUserTokenRepository returns a UserTokenRepository backed by db
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Replace(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ?", token.UserID, token.Purpose).Delete(&models.UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *userTokenRepository) FindByHash(ctx context.Context, purpose string, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := r.db.WithContext(ctx).Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) Use(ctx context.Context, token *models.UserToken, usedAt time.Time, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Conditional update so two concurrent requests cannot both use it
+		result := tx.Model(&models.UserToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", usedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStaleVersion
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(updates).Error
+	})
+}
+
+func (r *userTokenRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserToken{}).Error
+}
+
+func (r *userTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.UserToken{}).Error
+}
@@ -1,76 +1,150 @@
 package routes
 
 import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"notasGo/app"
 	"notasGo/controllers"
+	"notasGo/i18n"
+	"notasGo/middleware"
+	"notasGo/utils"
+	"path/filepath"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter() *gin.Engine {
-	r := gin.Default()
-	r.LoadHTMLGlob("templates/*")
+// SetupRouter builds the HTTP router on top of the services of the container
+func SetupRouter(container *app.Container) *gin.Engine {
+	cfg := container.Config
+
+	gin.SetMode(cfg.Server.Mode)
+	r := gin.New()
+	// The client IP keys the login limits and is logged, so the forwarding
+	// headers are only believed from the configured proxies, which Load
+	// already validated
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic(err)
+	}
+	r.Use(middleware.Tracing(), middleware.RequestLogger(slog.Default()), middleware.Metrics(container.Metrics), middleware.Recovery())
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+	}
+	r.Use(middleware.Locale(), middleware.ErrorHandler())
+	utils.RegisterFieldNames()
+
+	// Templates translate their texts with {{ t .Locale "message.id" }}
+	r.SetFuncMap(template.FuncMap{"t": func(locale string, id string) string {
+		return i18n.Translate(locale, id, nil)
+	}})
+	r.LoadHTMLGlob(filepath.Join(cfg.Server.TemplatesDir, "*"))
+
+	// Static files (CSS, images, JS, etc.)
+	r.Static("/static", cfg.Server.StaticDir)
+
+	// Initialize middleware
+	requireAuth := middleware.RequireAuth(container.TokenService, container.SessionService, container.UserService)
+	requireIfMatch := middleware.RequireIfMatch()
+	limitLogin := middleware.RateLimit(container.LoginLimiter, middleware.ClientIPKey)
+	limitUsers := middleware.RateLimit(container.UsersLimiter, middleware.UserKey)
+	limitNotes := middleware.RateLimit(container.NotesLimiter, middleware.UserKey)
+	limitLegacy := middleware.RateLimit(container.LegacyLimiter, middleware.UserKey)
 
 	// Initialize controllers
-	userController := controllers.NewUserController()
-	noteController := controllers.NewNoteController()
+	userController := controllers.NewUserController(container.UserService, container.SessionService, container.LockoutService, container.VerificationService, container.PasswordResetService, container.TokenService)
+	noteController := controllers.NewNoteController(container.NoteService)
+	tagController := controllers.NewTagController(container.TagService)
+	revisionController := controllers.NewRevisionController(container.RevisionService)
+	trashController := controllers.NewTrashController(container.TrashService)
+	homeController := controllers.NewHomeController(container.NoteService)
+	healthController := controllers.NewHealthController(container.HealthService)
+
+	// Probes for the container orchestrator
+	r.GET("/healthz", healthController.Healthz)
+	r.GET("/readyz", healthController.Readyz)
 
 	// API v1 routes group
 	v1 := r.Group("/api/v1")
 	{
 		// User routes
-		users := v1.Group("/users")
+		users := v1.Group("/users", requireAuth, limitUsers)
 		{
 			users.GET("", userController.GetUsers)
 			users.GET("/:id", userController.GetUserByID)
 			users.PUT("/:id", userController.UpdateUser)
 			users.DELETE("/:id", userController.DeleteUser)
+			users.POST("/:id/restore", userController.RestoreUser)
+			users.GET("/:id/lockout", userController.GetLockout)
+			users.DELETE("/:id/lockout", userController.ClearLockout)
 		}
 
 		// Authentication routes
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", userController.RegisterUser)
-			auth.POST("/login", userController.LoginUser)
+			auth.POST("/register", limitLogin, userController.RegisterUser)
+			auth.POST("/login", limitLogin, userController.LoginUser)
+			auth.POST("/refresh", userController.RefreshToken)
+			auth.POST("/logout", requireAuth, userController.LogoutUser)
+			auth.GET("/verify", userController.VerifyEmail)
+			auth.POST("/verify/resend", limitLogin, userController.ResendVerification)
+			auth.POST("/forgot-password", limitLogin, userController.ForgotPassword)
+			auth.POST("/reset-password", limitLogin, userController.ResetPassword)
 		}
 
 		// Note routes
-		notes := v1.Group("/notes")
+		notes := v1.Group("/notes", requireAuth, limitNotes)
 		{
 			notes.GET("", noteController.GetNotes)
+			notes.GET("/search", noteController.SearchNotes)
 			notes.GET("/:id", noteController.GetNoteByID)
 			notes.POST("", noteController.CreateNote)
-			notes.PUT("/:id", noteController.UpdateNote)
-			notes.PATCH("/:id", noteController.PatchNote)
-			notes.DELETE("/:id", noteController.DeleteNote)
+			notes.PUT("/:id", requireIfMatch, noteController.UpdateNote)
+			notes.PATCH("/:id", requireIfMatch, noteController.PatchNote)
+			notes.DELETE("/:id", requireIfMatch, noteController.DeleteNote)
+			notes.POST("/:id/restore", noteController.RestoreNote)
+
+			// Revision history
+			notes.GET("/:id/revisions", revisionController.GetRevisions)
+			notes.GET("/:id/revisions/diff", revisionController.DiffRevisions)
+			notes.GET("/:id/revisions/:rev", revisionController.GetRevision)
+			notes.POST("/:id/revisions/:rev/restore", requireIfMatch, revisionController.RestoreRevision)
 		}
 
+		// Trash routes
+		v1.GET("/trash", requireAuth, limitNotes, trashController.GetTrash)
+
+		// Tag routes
+		v1.GET("/tags", requireAuth, limitNotes, tagController.GetTags)
+
 		// User notes routes (moved outside users group to avoid conflicts)
-		v1.GET("/user/:user_id/notes", noteController.GetNotesByUser)
+		v1.GET("/user/:user_id/notes", requireAuth, limitNotes, noteController.GetNotesByUser)
 	}
 
+	// Legacy authentication routes (public)
+	r.POST("/register", limitLogin, userController.RegisterUser)
+	r.POST("/login", limitLogin, userController.LoginUser)
+
 	// Legacy routes for backward compatibility
-	legacy := r.Group("")
+	legacy := r.Group("", requireAuth, limitLegacy)
 	{
 		// Dashboard route
-		legacy.GET("/", controllers.Dashboard)
+		legacy.GET("/", homeController.Dashboard)
 
 		// Legacy user routes
 		legacy.GET("/users", userController.GetUsers)
 		legacy.GET("/users/:id", userController.GetUserByID)
 		legacy.PUT("/users/:id", userController.UpdateUser)
 		legacy.DELETE("/users/:id", userController.DeleteUser)
-		legacy.POST("/register", userController.RegisterUser)
-		legacy.POST("/login", userController.LoginUser)
 
 		// Legacy note routes
 		legacy.GET("/notes", noteController.GetNotes)
 		legacy.GET("/notes/:id", noteController.GetNoteByID)
 		legacy.POST("/notes", noteController.CreateNote)
-		legacy.PUT("/notes/:id", noteController.UpdateNote)
-		legacy.PATCH("/notes/:id", noteController.PatchNote)
-		legacy.DELETE("/notes/:id", noteController.DeleteNote)
+		legacy.PUT("/notes/:id", requireIfMatch, noteController.UpdateNote)
+		legacy.PATCH("/notes/:id", requireIfMatch, noteController.PatchNote)
+		legacy.DELETE("/notes/:id", requireIfMatch, noteController.DeleteNote)
 
 		// User notes route
 		legacy.GET("/user/:user_id/notes", noteController.GetNotesByUser)
@@ -85,4 +159,12 @@ func SetupRouter() *gin.Engine {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return r
-}
\ No newline at end of file
+}
+
+// SetupMetricsRouter builds the handler of the metrics listener, which only
+// serves /metrics for Prometheus
+func SetupMetricsRouter(container *app.Container) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", container.Metrics.Handler())
+	return mux
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// Background runs the work a request starts but does not wait for, such as
+// the emails whose timing would reveal which accounts exist
+type Background struct {
+	wg sync.WaitGroup
+}
+
+// NewBackground returns a Background with no work running
+func NewBackground() *Background {
+	return &Background{}
+}
+
+// Go runs fn in a new goroutine. Its context keeps the logger and trace of
+// ctx but is not canceled with the request.
+func (b *Background) Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn(ctx)
+	}()
+}
+
+// Wait blocks until the work started with Go finishes
+func (b *Background) Wait() {
+	b.wg.Wait()
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"notasGo/i18n"
+	"notasGo/models"
+	"strconv"
+	"time"
+)
+
+// ErrorKind classifies domain errors so the HTTP layer can choose a status code
+type ErrorKind int
+
+const (
+	KindValidation ErrorKind = iota + 1
+	KindUnauthorized
+	KindInactive
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+	KindPreconditionRequired
+	KindUnavailable
+	KindTooManyRequests
+)
+
+// Error is a domain error returned by the services. Code is a stable,
+// machine readable identifier. The text shown to users comes from the i18n
+// catalog entry MessageID, "error.<code>" when empty. Fields optionally lists
+// the request fields that caused the error, with message IDs as messages.
+// RetryAfter, when set, tells the client how long to wait before retrying.
+// Errors with the same Code match with errors.Is.
+type Error struct {
+	Kind       ErrorKind
+	Code       string
+	MessageID  string
+	Fields     []models.FieldError
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error returns the message in the default locale, for logs
+func (e *Error) Error() string {
+	message := i18n.Translate(i18n.DefaultLocale, e.Message(), nil)
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a domain error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Message returns the ID of the message describing the error
+func (e *Error) Message() string {
+	if e.MessageID != "" {
+		return e.MessageID
+	}
+	return "error." + e.Code
+}
+
+// WithMessage returns a copy of the error described by a more specific message ID
+func (e *Error) WithMessage(messageID string) *Error {
+	copied := *e
+	copied.MessageID = messageID
+	return &copied
+}
+
+// WithField returns a copy of the error pointing at the request field that caused it
+func (e *Error) WithField(field string, rule string, messageID string) *Error {
+	copied := *e
+	copied.Fields = append(append([]models.FieldError{}, e.Fields...), models.FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: messageID,
+	})
+	return &copied
+}
+
+// WithRetryAfter returns a copy of the error asking the client to wait d before retrying
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	copied := *e
+	copied.RetryAfter = d
+	return &copied
+}
+
+// Wrap returns a copy of the error carrying cause as its details
+func (e *Error) Wrap(cause error) *Error {
+	copied := *e
+	copied.Err = cause
+	return &copied
+}
+
+// fieldError builds the field details of an error tied to a single request field
+func fieldError(field string, rule string, messageID string) []models.FieldError {
+	return []models.FieldError{{Field: field, Rule: rule, Message: messageID}}
+}
+
+// Request errors
+var (
+	ErrInvalidRequest = &Error{Kind: KindValidation, Code: "invalid_request"}
+	ErrInvalidQuery   = &Error{Kind: KindValidation, Code: "invalid_query"}
+	ErrInvalidID      = &Error{Kind: KindValidation, Code: "invalid_id"}
+	ErrInvalidCursor  = &Error{Kind: KindValidation, Code: "invalid_cursor", Fields: fieldError("cursor", "cursor", "field.invalid_cursor")}
+	ErrForbidden      = &Error{Kind: KindForbidden, Code: "forbidden"}
+)
+
+// Authentication errors
+var (
+	ErrTokenRequired       = &Error{Kind: KindUnauthorized, Code: "token_required"}
+	ErrInvalidToken        = &Error{Kind: KindUnauthorized, Code: "invalid_token"}
+	ErrSessionRevoked      = &Error{Kind: KindUnauthorized, Code: "session_revoked"}
+	ErrInvalidRefreshToken = &Error{Kind: KindUnauthorized, Code: "invalid_refresh_token"}
+	ErrInvalidCredentials  = &Error{Kind: KindUnauthorized, Code: "invalid_credentials"}
+	ErrAccountInactive     = &Error{Kind: KindInactive, Code: "account_inactive"}
+	ErrEmailNotVerified    = &Error{Kind: KindInactive, Code: "email_not_verified"}
+	ErrTooManyRequests     = &Error{Kind: KindTooManyRequests, Code: "too_many_requests"}
+	ErrAccountLocked       = &Error{Kind: KindTooManyRequests, Code: "account_locked"}
+
+	ErrInvalidVerificationToken = &Error{Kind: KindValidation, Code: "invalid_verification_token"}
+	ErrInvalidResetToken        = &Error{Kind: KindValidation, Code: "invalid_reset_token"}
+)
+
+// User errors
+var (
+	ErrUserNotFound   = &Error{Kind: KindNotFound, Code: "user_not_found"}
+	ErrUserNotInTrash = &Error{Kind: KindNotFound, Code: "user_not_in_trash"}
+	ErrEmailTaken     = &Error{Kind: KindConflict, Code: "email_taken", Fields: fieldError("email", "unique", "field.email_taken")}
+	ErrUsernameTaken  = &Error{Kind: KindConflict, Code: "username_taken", Fields: fieldError("username", "unique", "field.username_taken")}
+)
+
+// Note errors
+var (
+	ErrNoteNotFound      = &Error{Kind: KindNotFound, Code: "note_not_found"}
+	ErrNoteNotInTrash    = &Error{Kind: KindNotFound, Code: "note_not_in_trash"}
+	ErrNoteOwnerDeleted  = &Error{Kind: KindConflict, Code: "note_owner_deleted"}
+	ErrRevisionNotFound  = &Error{Kind: KindNotFound, Code: "revision_not_found"}
+	ErrFieldNotPatchable = &Error{Kind: KindValidation, Code: "field_not_patchable"}
+	ErrInvalidTags       = &Error{Kind: KindValidation, Code: "invalid_tags", Fields: fieldError("tags", "type", "field.invalid_tags")}
+	ErrContentTooLong    = &Error{Kind: KindValidation, Code: "content_too_long", Fields: []models.FieldError{{Field: "content", Rule: "max", Param: strconv.Itoa(models.MaxNoteContentLength), Message: "validation.max_length"}}}
+	ErrDiffTooLarge      = &Error{Kind: KindValidation, Code: "diff_too_large"}
+	ErrVersionMismatch   = &Error{Kind: KindPreconditionFailed, Code: "version_mismatch"}
+	ErrIfMatchRequired   = &Error{Kind: KindPreconditionRequired, Code: "if_match_required"}
+	ErrConcurrentUpdate  = &Error{Kind: KindConflict, Code: "concurrent_update"}
+	ErrEmptySearchQuery  = &Error{Kind: KindValidation, Code: "empty_search_query", Fields: fieldError("q", "required", "field.empty_search_query")}
+	ErrSearchUnavailable = &Error{Kind: KindUnavailable, Code: "search_unavailable"}
+)
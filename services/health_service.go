@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// readinessTimeout bounds the checks of a readiness probe, so a stuck
+// database makes the probe fail instead of hang
+const readinessTimeout = 2 * time.Second
+
+// DatabaseChecker is what the readiness probe needs from the database
+type DatabaseChecker interface {
+	Ping(ctx context.Context) error
+	MigrationStatus(ctx context.Context) (applied int, pending int, err error)
+}
+
+// Readiness is the outcome of a readiness check
+type Readiness struct {
+	// DatabaseErr is set when the database did not answer
+	DatabaseErr error
+	// MigrationsErr is set when the migration status could not be read
+	MigrationsErr     error
+	AppliedMigrations int
+	PendingMigrations int
+}
+
+// Ready reports whether the application can serve requests: the database
+// answers and its schema is up to date
+func (r Readiness) Ready() bool {
+	return r.DatabaseErr == nil && r.MigrationsErr == nil && r.PendingMigrations == 0
+}
+
+type HealthService struct {
+	db DatabaseChecker
+}
+
+func NewHealthService(db DatabaseChecker) *HealthService {
+	return &HealthService{db: db}
+}
+
+// CheckReadiness pings the database and reads the migration status
+func (s *HealthService) CheckReadiness(ctx context.Context) Readiness {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	var readiness Readiness
+	if readiness.DatabaseErr = s.db.Ping(ctx); readiness.DatabaseErr != nil {
+		return readiness
+	}
+	readiness.AppliedMigrations, readiness.PendingMigrations, readiness.MigrationsErr = s.db.MigrationStatus(ctx)
+	return readiness
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"notasGo/logging"
+	"notasGo/models"
+	"notasGo/ratelimit"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"strings"
+	"time"
+)
+
+// freeLoginFailures is the number of failed logins in a row allowed without
+// delay. From then on each failure makes the client wait twice as long
+// before the next attempt, starting at firstLoginDelay.
+const (
+	freeLoginFailures = 3
+	firstLoginDelay   = time.Second
+)
+
+type LockoutService struct {
+	lockouts  repositories.LockoutRepository
+	accounts  *ratelimit.Limiter
+	threshold int
+	duration  time.Duration
+}
+
+// NewLockoutService protects the logins of each email with the accounts
+// limiter, progressive delays after failures, and a lockout of duration once
+// threshold failures in a row are reached
+func NewLockoutService(lockouts repositories.LockoutRepository, accounts *ratelimit.Limiter, threshold int, duration time.Duration) *LockoutService {
+	return &LockoutService{
+		lockouts:  lockouts,
+		accounts:  accounts,
+		threshold: threshold,
+		duration:  duration,
+	}
+}
+
+// normalizeEmail makes "Ana@example.com " and "ana@example.com" the same
+// key, as emails are unique regardless of case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check reports whether a login for email may be attempted now. It takes an
+// attempt from the bucket of the email and returns ErrTooManyRequests when
+// it is empty, or ErrAccountLocked while the email is delayed or locked.
+// If the bucket cannot be read only the lockout is checked.
+func (s *LockoutService) Check(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.Check")
+	defer span.End()
+
+	email = normalizeEmail(email)
+	result, err := s.accounts.Allow(ctx, email)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Límite de peticiones no disponible", slog.Any("error", err))
+	} else if !result.Allowed {
+		return ErrTooManyRequests.WithRetryAfter(result.RetryAfter)
+	}
+
+	lockout, err := s.lockouts.Find(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if now := time.Now(); lockout.IsLocked(now) {
+		return ErrAccountLocked.WithRetryAfter(lockout.LockedUntil.Sub(now))
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login for email and delays or locks the
+// next attempts when the failures in a row pass the free ones
+func (s *LockoutService) RecordFailure(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordFailure")
+	defer span.End()
+
+	email, now := normalizeEmail(email), time.Now()
+
+	// Failures old enough to be forgotten are of no use to anyone
+	if err := s.lockouts.DeleteStale(ctx, now.Add(-s.duration)); err != nil {
+		return err
+	}
+
+	lockout, err := s.lockouts.RecordFailure(ctx, email, now, now.Add(-s.duration))
+	if err != nil {
+		return err
+	}
+	if delay := s.delay(lockout.Failures); delay > 0 {
+		return s.lockouts.Lock(ctx, email, now.Add(delay))
+	}
+	return nil
+}
+
+// RecordSuccess forgets the failures of email after a successful login
+func (s *LockoutService) RecordSuccess(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordSuccess")
+	defer span.End()
+
+	return s.lockouts.Delete(ctx, normalizeEmail(email))
+}
+
+// GetLockout returns the failures recorded for email, an empty lockout if
+// there are none
+func (s *LockoutService) GetLockout(ctx context.Context, email string) (*models.LoginLockout, error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.GetLockout")
+	defer span.End()
+
+	email = normalizeEmail(email)
+	lockout, err := s.lockouts.Find(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return &models.LoginLockout{Email: email}, nil
+		}
+		return nil, err
+	}
+	return lockout, nil
+}
+
+// ClearLockout forgets the failures and the attempts of email, so its
+// owner can log in again right away
+func (s *LockoutService) ClearLockout(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.ClearLockout")
+	defer span.End()
+
+	email = normalizeEmail(email)
+	if err := s.lockouts.Delete(ctx, email); err != nil {
+		return err
+	}
+	return s.accounts.Reset(ctx, email)
+}
+
+// delay returns how long the logins are refused after the given number of
+// failures in a row: nothing for the free ones, then doubling delays capped
+// at the lockout duration, which applies in full from the threshold on
+func (s *LockoutService) delay(failures int) time.Duration {
+	if failures >= s.threshold {
+		return s.duration
+	}
+	if failures <= freeLoginFailures {
+		return 0
+	}
+	delay := firstLoginDelay
+	for i := freeLoginFailures + 1; i < failures && delay < s.duration; i++ {
+		delay *= 2
+	}
+	return min(delay, s.duration)
+}
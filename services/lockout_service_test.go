@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"notasGo/app/apptest"
+	"notasGo/config"
+	"notasGo/ratelimit"
+	"notasGo/services"
+	"testing"
+	"time"
+)
+
+// lockoutDelay returns how long the logins of email are refused after its
+// last failure
+func lockoutDelay(t *testing.T, a *apptest.App, email string) time.Duration {
+	t.Helper()
+
+	lockout, err := a.Container.LockoutService.GetLockout(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockout.LockedUntil == nil {
+		return 0
+	}
+	return lockout.LockedUntil.Sub(lockout.LastFailureAt).Round(time.Second)
+}
+
+func TestLockoutServiceDelaysGrow(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 10
+		cfg.Auth.LockoutDuration = 15 * time.Minute
+	})
+	ctx := context.Background()
+	lockouts := a.Container.LockoutService
+
+	// Three free failures, then doubling delays until the threshold locks
+	// the email for the whole duration
+	want := []time.Duration{
+		0, 0, 0,
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+		15 * time.Minute, 15 * time.Minute,
+	}
+	for i, delay := range want {
+		// Emails count the same whatever their case
+		if err := lockouts.RecordFailure(ctx, "Ana@Example.com "); err != nil {
+			t.Fatal(err)
+		}
+		if got := lockoutDelay(t, a, "ana@example.com"); got != delay {
+			t.Errorf("fallo %d: espera de %s, se esperaba %s", i+1, got, delay)
+		}
+	}
+
+	lockout, err := lockouts.GetLockout(ctx, "ana@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockout.Failures != len(want) {
+		t.Errorf("%d fallos registrados, se esperaban %d", lockout.Failures, len(want))
+	}
+}
+
+func TestLockoutServiceDelaysAreCapped(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 10
+		cfg.Auth.LockoutDuration = 3 * time.Second
+	})
+
+	want := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for i, delay := range want {
+		if err := a.Container.LockoutService.RecordFailure(context.Background(), "ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if got := lockoutDelay(t, a, "ana@example.com"); got != delay {
+			t.Errorf("fallo %d: espera de %s, se esperaba %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestLockoutServiceCheck(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = 5
+		cfg.Auth.LockoutDuration = time.Hour
+	})
+	ctx := context.Background()
+	lockouts := a.Container.LockoutService
+
+	for range 3 {
+		if err := lockouts.RecordFailure(ctx, "ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lockouts.Check(ctx, "ana@example.com"); err != nil {
+		t.Fatalf("fallos gratuitos bloquearon el login: %v", err)
+	}
+
+	// A success forgets the failures
+	if err := lockouts.RecordSuccess(ctx, "ana@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := lockouts.RecordFailure(ctx, "ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := lockoutDelay(t, a, "ana@example.com"); got != 0 {
+		t.Fatalf("el éxito no borró los fallos anteriores, espera de %s", got)
+	}
+
+	// The fifth failure in a row reaches the threshold
+	for range 2 {
+		if err := lockouts.RecordFailure(ctx, "ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := lockouts.Check(ctx, "ana@example.com")
+	var domainErr *services.Error
+	if !errors.As(err, &domainErr) || !errors.Is(err, services.ErrAccountLocked) {
+		t.Fatalf("error %v, se esperaba account_locked", err)
+	}
+	if domainErr.RetryAfter <= 59*time.Minute || domainErr.RetryAfter > time.Hour {
+		t.Errorf("Retry-After de %s, se esperaba una hora", domainErr.RetryAfter)
+	}
+	if err := lockouts.Check(ctx, "otra@example.com"); err != nil {
+		t.Errorf("el bloqueo afectó a otro email: %v", err)
+	}
+
+	if err := lockouts.ClearLockout(ctx, "ana@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockouts.Check(ctx, "ana@example.com"); err != nil {
+		t.Errorf("el email sigue bloqueado tras levantar el bloqueo: %v", err)
+	}
+}
+
+func TestLockoutServiceLimitsAttempts(t *testing.T) {
+	a := apptest.New(t, func(cfg *config.Config) {
+		cfg.Auth.LoginAccountRate = ratelimit.Rate{Limit: 2, Period: time.Minute}
+	})
+	ctx := context.Background()
+	lockouts := a.Container.LockoutService
+
+	for range 2 {
+		if err := lockouts.Check(ctx, "ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lockouts.Check(ctx, "ANA@example.com"); !errors.Is(err, services.ErrTooManyRequests) {
+		t.Errorf("error %v, se esperaba too_many_requests", err)
+	}
+
+	// Lifting the lockout also refills the bucket
+	if err := lockouts.ClearLockout(ctx, "ana@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockouts.Check(ctx, "ana@example.com"); err != nil {
+		t.Errorf("el email sigue limitado tras levantar el bloqueo: %v", err)
+	}
+}
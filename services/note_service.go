@@ -1,69 +1,103 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"notasGo/database"
 	"notasGo/models"
-
-	"gorm.io/gorm"
+	"notasGo/policy"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"slices"
+	"strings"
+	"unicode/utf8"
 )
 
 type NoteService struct {
+	notes       repositories.NoteRepository
 	userService *UserService
 }
 
-func NewNoteService() *NoteService {
+func NewNoteService(notes repositories.NoteRepository, userService *UserService) *NoteService {
 	return &NoteService{
-		userService: NewUserService(),
+		notes:       notes,
+		userService: userService,
+	}
+}
+
+// patchableNoteFields lists the fields a client may change through PatchNote
+var patchableNoteFields = map[string]bool{
+	"title":   true,
+	"content": true,
+	"tags":    true,
+}
+
+// visibleOwner returns the owner the note lookups of the caller are restricted to.
+// Admins can see every note (0), other users only their own.
+func visibleOwner(caller *models.User) uint {
+	if policy.CanViewAllNotes(caller) {
+		return 0
 	}
+	return caller.ID
 }
 
-// GetAllNotes retrieves all notes with user information
-func (s *NoteService) GetAllNotes() ([]models.Note, int64, error) {
-	var notes []models.Note
-	var count int64
-	
-	if err := database.DB.Preload("User").Find(&notes).Error; err != nil {
-		return nil, 0, err
+// GetAllNotes retrieves a page of the notes visible by the caller with user information
+func (s *NoteService) GetAllNotes(ctx context.Context, caller *models.User, query *models.NoteListQuery) ([]models.Note, int64, models.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetAllNotes")
+	defer span.End()
+
+	filter := repositories.NoteFilter{
+		OwnerID: visibleOwner(caller),
+		UserID:  query.UserID,
 	}
-	
-	database.DB.Model(&models.Note{}).Count(&count)
-	return notes, count, nil
+	return s.listNotes(ctx, filter, query)
+}
+
+// GetVisibleNotes retrieves every note visible by the caller, without user information
+func (s *NoteService) GetVisibleNotes(ctx context.Context, caller *models.User) ([]models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetVisibleNotes")
+	defer span.End()
+
+	return s.notes.FindAll(ctx, visibleOwner(caller))
 }
 
-// GetNoteByID retrieves a note by ID with user information
-func (s *NoteService) GetNoteByID(id string) (*models.Note, error) {
-	var note models.Note
-	if err := database.DB.Preload("User").First(&note, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("nota no encontrada")
+// GetNoteByID retrieves a note by ID with user information.
+// Notes owned by someone else are reported as not found unless the caller is an admin.
+func (s *NoteService) GetNoteByID(ctx context.Context, id uint, caller *models.User) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNoteByID")
+	defer span.End()
+
+	note, err := s.notes.FindByID(ctx, id, visibleOwner(caller))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrNoteNotFound
 		}
 		return nil, err
 	}
-	return &note, nil
+	return note, nil
 }
 
-// CreateNote creates a new note
-func (s *NoteService) CreateNote(req *models.CreateNoteRequest) (*models.Note, error) {
-	// Verify user exists
-	_, err := s.userService.GetUserByID(fmt.Sprintf("%d", req.UserID))
-	if err != nil {
-		return nil, errors.New("usuario no encontrado")
+// CreateNote creates a new note owned by the caller
+func (s *NoteService) CreateNote(ctx context.Context, req *models.CreateNoteRequest, caller *models.User) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.CreateNote")
+	defer span.End()
+
+	if err := checkContent(req.Content); err != nil {
+		return nil, err
 	}
 
 	note := models.Note{
 		Title:   req.Title,
 		Content: req.Content,
-		UserID:  req.UserID,
+		UserID:  caller.ID,
 	}
 
-	if err := database.DB.Create(&note).Error; err != nil {
+	if err := s.notes.Create(ctx, &note, req.Tags); err != nil {
 		return nil, err
 	}
 
 	// Load user information for response
-	createdNote, err := s.GetNoteByID(fmt.Sprintf("%d", note.ID))
+	createdNote, err := s.GetNoteByID(ctx, uint(note.ID), caller)
 	if err != nil {
 		return nil, err
 	}
@@ -71,21 +105,17 @@ func (s *NoteService) CreateNote(req *models.CreateNoteRequest) (*models.Note, e
 	return createdNote, nil
 }
 
-// UpdateNote updates an existing note
-func (s *NoteService) UpdateNote(id string, req *models.UpdateNoteRequest) (*models.Note, error) {
-	note, err := s.GetNoteByID(id)
+// UpdateNote updates an existing note visible by the caller.
+// When expectedVersions is not empty the update only succeeds if the note still has one of those versions.
+func (s *NoteService) UpdateNote(ctx context.Context, id uint, req *models.UpdateNoteRequest, caller *models.User, expectedVersions []int) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.UpdateNote")
+	defer span.End()
+
+	note, err := s.GetNoteByID(ctx, id, caller)
 	if err != nil {
 		return nil, err
 	}
 
-	// If UserID is being changed, verify the new user exists
-	if req.UserID != 0 && req.UserID != note.UserID {
-		_, err := s.userService.GetUserByID(fmt.Sprintf("%d", req.UserID))
-		if err != nil {
-			return nil, errors.New("usuario no encontrado")
-		}
-	}
-
 	// Update fields
 	updates := make(map[string]interface{})
 	if req.Title != "" {
@@ -94,16 +124,14 @@ func (s *NoteService) UpdateNote(id string, req *models.UpdateNoteRequest) (*mod
 	if req.Content != "" {
 		updates["content"] = req.Content
 	}
-	if req.UserID != 0 {
-		updates["user_id"] = req.UserID
-	}
 
-	if err := database.DB.Model(note).Updates(updates).Error; err != nil {
+	// A nil tag list leaves the tags untouched, an empty one removes them
+	if err := s.saveNote(ctx, note, updates, req.Tags, caller, expectedVersions); err != nil {
 		return nil, err
 	}
 
 	// Return updated note with user information
-	updatedNote, err := s.GetNoteByID(id)
+	updatedNote, err := s.GetNoteByID(ctx, id, caller)
 	if err != nil {
 		return nil, err
 	}
@@ -111,29 +139,38 @@ func (s *NoteService) UpdateNote(id string, req *models.UpdateNoteRequest) (*mod
 	return updatedNote, nil
 }
 
-// PatchNote partially updates a note
-func (s *NoteService) PatchNote(id string, updates map[string]interface{}) (*models.Note, error) {
-	note, err := s.GetNoteByID(id)
+// PatchNote partially updates a note visible by the caller.
+// Only title, content and tags can be changed, ownership stays with the original author.
+func (s *NoteService) PatchNote(ctx context.Context, id uint, updates map[string]interface{}, caller *models.User, expectedVersions []int) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.PatchNote")
+	defer span.End()
+
+	note, err := s.GetNoteByID(ctx, id, caller)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate UserID if present
-	if userID, exists := updates["user_id"]; exists {
-		if uid, ok := userID.(float64); ok {
-			_, err := s.userService.GetUserByID(fmt.Sprintf("%d", int(uid)))
-			if err != nil {
-				return nil, errors.New("usuario no encontrado")
+	allowed := make(map[string]interface{})
+	var tags []string
+	for field, value := range updates {
+		if !patchableNoteFields[field] {
+			return nil, ErrFieldNotPatchable.WithField(field, "patchable", "field.not_patchable")
+		}
+		if field == "tags" {
+			if tags, err = parseTagList(value); err != nil {
+				return nil, err
 			}
+			continue
 		}
+		allowed[field] = value
 	}
 
-	if err := database.DB.Model(note).Updates(updates).Error; err != nil {
+	if err := s.saveNote(ctx, note, allowed, tags, caller, expectedVersions); err != nil {
 		return nil, err
 	}
 
 	// Return updated note with user information
-	updatedNote, err := s.GetNoteByID(id)
+	updatedNote, err := s.GetNoteByID(ctx, id, caller)
 	if err != nil {
 		return nil, err
 	}
@@ -141,36 +178,197 @@ func (s *NoteService) PatchNote(id string, updates map[string]interface{}) (*mod
 	return updatedNote, nil
 }
 
-// DeleteNote deletes a note by ID
-func (s *NoteService) DeleteNote(id string) error {
-	note, err := s.GetNoteByID(id)
+// DeleteNote moves a note visible by the caller to the trash.
+// Its tags and revisions are kept so it can be restored until the trash is purged.
+// When expectedVersions is not empty the note is only deleted if it still has one of those versions.
+func (s *NoteService) DeleteNote(ctx context.Context, id uint, caller *models.User, expectedVersions []int) error {
+	ctx, span := tracing.Start(ctx, "NoteService.DeleteNote")
+	defer span.End()
+
+	note, err := s.GetNoteByID(ctx, id, caller)
 	if err != nil {
 		return err
 	}
+	if !versionAllowed(expectedVersions, note.Version) {
+		return ErrVersionMismatch
+	}
 
-	if err := database.DB.Delete(note).Error; err != nil {
-		return errors.New("error al eliminar nota")
+	// With a precondition the delete is conditional on the version read
+	version := 0
+	if len(expectedVersions) > 0 {
+		version = note.Version
+	}
+	if err := s.notes.Delete(ctx, note.ID, version); err != nil {
+		if !errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("error al eliminar nota: %w", err)
+		}
+		if version != 0 {
+			return ErrVersionMismatch
+		}
+		return ErrNoteNotFound
 	}
 
 	return nil
 }
 
-// GetNotesByUser retrieves all notes for a specific user
-func (s *NoteService) GetNotesByUser(userID string) (*models.User, []models.Note, int64, error) {
+// RestoreNote takes a note visible by the caller out of the trash.
+// Notes whose owner is also in the trash can only come back with the account.
+func (s *NoteService) RestoreNote(ctx context.Context, id uint, caller *models.User) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.RestoreNote")
+	defer span.End()
+
+	note, err := s.notes.FindDeleted(ctx, id, visibleOwner(caller))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrNoteNotInTrash
+		}
+		return nil, err
+	}
+
+	if _, err := s.userService.GetUserByID(ctx, note.UserID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrNoteOwnerDeleted
+		}
+		return nil, err
+	}
+
+	if err := s.notes.Restore(ctx, note); err != nil {
+		return nil, fmt.Errorf("error al restaurar nota: %w", err)
+	}
+
+	return s.GetNoteByID(ctx, id, caller)
+}
+
+// saveNote applies column updates and, when tags is not nil, replaces the tags of the note.
+// If the title or content change, the previous version is kept as a revision authored by editor.
+//
+// Every save bumps the note version with a conditional UPDATE on the version
+// that was read, so a concurrent write makes it fail instead of being
+// overwritten. When expectedVersions (from If-Match) is not empty it must include that version.
+func (s *NoteService) saveNote(ctx context.Context, note *models.Note, updates map[string]interface{}, tags []string, editor *models.User, expectedVersions []int) error {
+	if !versionAllowed(expectedVersions, note.Version) {
+		return ErrVersionMismatch
+	}
+	if content, ok := updates["content"].(string); ok {
+		if err := checkContent(content); err != nil {
+			return err
+		}
+	}
+
+	update := repositories.NoteUpdate{Columns: updates, Tags: tags}
+	if contentChanged(note, updates) {
+		update.Revision = &models.NoteRevision{
+			NoteID:   note.ID,
+			Title:    note.Title,
+			Content:  note.Content,
+			AuthorID: editor.ID,
+		}
+	}
+
+	if err := s.notes.Update(ctx, note, update); err != nil {
+		if !errors.Is(err, repositories.ErrStaleVersion) {
+			return err
+		}
+		if len(expectedVersions) > 0 {
+			return ErrVersionMismatch
+		}
+		return ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// checkContent refuses the contents longer than models.MaxNoteContentLength,
+// whichever handler they come from
+func checkContent(content string) error {
+	if utf8.RuneCountInString(content) > models.MaxNoteContentLength {
+		return ErrContentTooLong
+	}
+	return nil
+}
+
+// GetNotesByUser retrieves a page of the notes of a specific user
+func (s *NoteService) GetNotesByUser(ctx context.Context, userID uint, query *models.NoteListQuery) (*models.User, []models.Note, int64, models.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.GetNotesByUser")
+	defer span.End()
+
 	// Verify user exists
-	user, err := s.userService.GetUserByID(userID)
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, 0, models.PageInfo{}, err
+	}
+
+	notes, count, page, err := s.listNotes(ctx, repositories.NoteFilter{UserID: user.ID}, query)
+	if err != nil {
+		return nil, nil, 0, models.PageInfo{}, err
+	}
+
+	return user, notes, count, page, nil
+}
+
+// listNotes counts the notes matched by filter and the tags of the query and loads the requested page
+func (s *NoteService) listNotes(ctx context.Context, filter repositories.NoteFilter, query *models.NoteListQuery) ([]models.Note, int64, models.PageInfo, error) {
+	page, err := newPageRequest(query.PageQuery, query.Sort)
+	if err != nil {
+		return nil, 0, models.PageInfo{}, err
+	}
+	slice, err := page.toPage()
+	if err != nil {
+		return nil, 0, models.PageInfo{}, err
+	}
+
+	filter.Tags = query.Tags
+	filter.TagMode = query.TagMode
+	notes, count, err := s.notes.List(ctx, filter, slice)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, 0, models.PageInfo{}, err
+	}
+
+	notes, info := finishPage(page, notes, func(note models.Note) (string, int64) {
+		return sortKey(page.sort, note.CreatedAt, note.UpdatedAt, note.Title), int64(note.ID)
+	})
+	return notes, count, info, nil
+}
+
+// contentChanged reports whether the updates modify the title or content of the note
+func contentChanged(note *models.Note, updates map[string]interface{}) bool {
+	if title, ok := updates["title"]; ok && title != note.Title {
+		return true
+	}
+	if content, ok := updates["content"]; ok && content != note.Content {
+		return true
+	}
+	return false
+}
+
+// versionAllowed reports whether a note with version satisfies the versions
+// listed by If-Match. Without versions any version is allowed.
+func versionAllowed(expectedVersions []int, version int) bool {
+	return len(expectedVersions) == 0 || slices.Contains(expectedVersions, version)
+}
+
+// SearchNotes runs a full-text search over the notes visible by the caller.
+// Results are ordered by relevance and their fragments are escaped HTML with
+// the matches wrapped in <mark> tags.
+func (s *NoteService) SearchNotes(ctx context.Context, query *models.NoteSearchQuery, caller *models.User) ([]repositories.SearchHit, error) {
+	ctx, span := tracing.Start(ctx, "NoteService.SearchNotes")
+	defer span.End()
+
+	if !s.notes.SearchEnabled() {
+		return nil, ErrSearchUnavailable
 	}
 
-	var notes []models.Note
-	var count int64
-	
-	if err := database.DB.Preload("User").Where("user_id = ?", userID).Find(&notes).Error; err != nil {
-		return nil, nil, 0, err
+	terms := strings.Fields(query.Q)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
 	}
-	
-	database.DB.Model(&models.Note{}).Where("user_id = ?", userID).Count(&count)
-	
-	return user, notes, count, nil
-}
\ No newline at end of file
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	return s.notes.Search(ctx, terms, visibleOwner(caller), limit)
+}
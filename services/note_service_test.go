@@ -0,0 +1,316 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"notasGo/app/apptest"
+	"notasGo/models"
+	"notasGo/services"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signUp registers username through the API and returns its account
+func signUp(t *testing.T, a *apptest.App, username string) *models.User {
+	t.Helper()
+
+	user, err := a.Container.UserService.GetUserByID(context.Background(), a.SignUp(username).ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// noteTags returns the names of the tags of note
+func noteTags(note *models.Note) []string {
+	names := make([]string, 0, len(note.Tags))
+	for _, tag := range note.Tags {
+		names = append(names, tag.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestNoteServiceVersionsAndRevisions(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	user := signUp(t, a, "user")
+	notes := a.Container.NoteService
+	revisions := a.Container.RevisionService
+
+	note, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: "Primera", Content: "uno"}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Version != 1 || note.User.ID != user.ID {
+		t.Fatalf("nota creada con versión %d y autor %d", note.Version, note.User.ID)
+	}
+	id := uint(note.ID)
+
+	note, err = notes.UpdateNote(ctx, id, &models.UpdateNoteRequest{Title: "Segunda", Content: "dos"}, user, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Version != 2 || note.Title != "Segunda" {
+		t.Fatalf("nota actualizada con versión %d y título %q", note.Version, note.Title)
+	}
+
+	// A write based on the old version is refused and changes nothing
+	_, err = notes.UpdateNote(ctx, id, &models.UpdateNoteRequest{Content: "perdido"}, user, []int{1})
+	if !errors.Is(err, services.ErrVersionMismatch) {
+		t.Fatalf("error %v, se esperaba version_mismatch", err)
+	}
+	_, err = notes.PatchNote(ctx, id, map[string]interface{}{"content": "tres"}, user, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := revisions.GetRevisions(ctx, id, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Number != 2 || history[1].Number != 1 {
+		t.Fatalf("%d revisiones, se esperaban la 2 y la 1", len(history))
+	}
+	if history[1].Title != "Primera" || history[1].Content != "uno" {
+		t.Errorf("la revisión 1 guarda %q/%q", history[1].Title, history[1].Content)
+	}
+
+	diff, err := revisions.DiffRevisions(ctx, id, "1", services.CurrentRevision, user, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff.Diff, "--- revision 1\n+++ current\n") || !strings.Contains(diff.Diff, "-uno") || !strings.Contains(diff.Diff, "+tres") {
+		t.Errorf("diff inesperado:\n%s", diff.Diff)
+	}
+
+	// Restoring is one more update, so the replaced content is kept too
+	note, err = revisions.RestoreRevision(ctx, id, 1, user, []int{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Version != 4 || note.Title != "Primera" || note.Content != "uno" {
+		t.Errorf("restaurada con versión %d, título %q y contenido %q", note.Version, note.Title, note.Content)
+	}
+	if _, err := revisions.GetRevision(ctx, id, 3, user); err != nil {
+		t.Errorf("no se guardó la revisión 3: %v", err)
+	}
+	if _, err := revisions.GetRevision(ctx, id, 9, user); !errors.Is(err, services.ErrRevisionNotFound) {
+		t.Errorf("error %v, se esperaba revision_not_found", err)
+	}
+}
+
+func TestNoteServiceOwnership(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	owner := signUp(t, a, "owner")
+	other := signUp(t, a, "other")
+	notes := a.Container.NoteService
+
+	note, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: "Privada", Content: "secreto"}, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uint(note.ID)
+
+	if _, err := notes.GetNoteByID(ctx, id, other); !errors.Is(err, services.ErrNoteNotFound) {
+		t.Errorf("lectura ajena: error %v, se esperaba note_not_found", err)
+	}
+	if _, err := notes.UpdateNote(ctx, id, &models.UpdateNoteRequest{Title: "Robada"}, other, nil); !errors.Is(err, services.ErrNoteNotFound) {
+		t.Errorf("escritura ajena: error %v, se esperaba note_not_found", err)
+	}
+	if err := notes.DeleteNote(ctx, id, other, nil); !errors.Is(err, services.ErrNoteNotFound) {
+		t.Errorf("borrado ajeno: error %v, se esperaba note_not_found", err)
+	}
+
+	// Admins see every note
+	admin := signUp(t, a, "admin")
+	if _, err := a.Container.UserService.UpdateUser(ctx, admin.ID, &models.UpdateUserRequest{Role: models.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	admin.Role = models.RoleAdmin
+	if _, err := notes.GetNoteByID(ctx, id, admin); err != nil {
+		t.Errorf("un admin no ve la nota: %v", err)
+	}
+}
+
+func TestNoteServiceTags(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	user := signUp(t, a, "user")
+	notes := a.Container.NoteService
+
+	create := func(title string, tags ...string) *models.Note {
+		t.Helper()
+		note, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: title, Content: title, Tags: tags}, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return note
+	}
+	both := create("Ambas", "Trabajo", " ideas ", "trabajo")
+	create("Trabajo", "trabajo")
+	create("Sin etiquetas")
+
+	// Names are trimmed, lowercased and deduplicated
+	if got := noteTags(both); !slices.Equal(got, []string{"ideas", "trabajo"}) {
+		t.Errorf("etiquetas %v, se esperaban [ideas trabajo]", got)
+	}
+
+	count := func(mode string, tags ...string) int64 {
+		t.Helper()
+		query := &models.NoteListQuery{Tags: tags, TagMode: mode}
+		_, total, _, err := notes.GetAllNotes(ctx, user, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+	if got := count("any", "trabajo", "ideas"); got != 2 {
+		t.Errorf("tag_mode=any: %d notas, se esperaban 2", got)
+	}
+	if got := count("all", "trabajo", "ideas"); got != 1 {
+		t.Errorf("tag_mode=all: %d notas, se esperaba 1", got)
+	}
+
+	usage, err := a.Container.TagService.GetTags(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, tag := range usage {
+		counts[tag.Name] = tag.Count
+	}
+	if counts["trabajo"] != 2 || counts["ideas"] != 1 || len(counts) != 2 {
+		t.Errorf("uso de etiquetas %v, se esperaba trabajo=2 e ideas=1", counts)
+	}
+
+	// An empty list removes the tags, a nil one keeps them
+	note, err := notes.UpdateNote(ctx, uint(both.ID), &models.UpdateNoteRequest{Title: "Renombrada"}, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(note.Tags) != 2 {
+		t.Errorf("la actualización sin etiquetas dejó %v", noteTags(note))
+	}
+	note, err = notes.UpdateNote(ctx, uint(both.ID), &models.UpdateNoteRequest{Tags: []string{}}, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(note.Tags) != 0 {
+		t.Errorf("la lista vacía dejó %v", noteTags(note))
+	}
+}
+
+func TestNoteServiceTrash(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	user := signUp(t, a, "user")
+	notes := a.Container.NoteService
+	trash := a.Container.TrashService
+
+	kept, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: "Restaurada", Content: "uno", Tags: []string{"papelera"}}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purged, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: "Purgada", Content: "dos"}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, note := range []*models.Note{kept, purged} {
+		if err := notes.DeleteNote(ctx, uint(note.ID), user, []int{note.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, _, err := trash.GetTrash(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 {
+		t.Fatalf("%d notas en la papelera, se esperaban 2", len(deleted))
+	}
+	if _, err := notes.GetNoteByID(ctx, uint(kept.ID), user); !errors.Is(err, services.ErrNoteNotFound) {
+		t.Errorf("nota borrada visible: error %v", err)
+	}
+
+	restored, err := notes.RestoreNote(ctx, uint(kept.ID), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := noteTags(restored); !slices.Equal(got, []string{"papelera"}) {
+		t.Errorf("restaurada con etiquetas %v", got)
+	}
+	if _, err := notes.RestoreNote(ctx, uint(kept.ID), user); !errors.Is(err, services.ErrNoteNotInTrash) {
+		t.Errorf("segunda restauración: error %v, se esperaba note_not_in_trash", err)
+	}
+
+	// Nothing expires before the retention
+	result, err := trash.Purge(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Notes != 0 {
+		t.Errorf("purgadas %d notas antes de tiempo", result.Notes)
+	}
+	result, err = trash.Purge(ctx, time.Now().Add(trash.Retention()+time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Notes != 1 {
+		t.Errorf("purgadas %d notas, se esperaba 1", result.Notes)
+	}
+	if _, err := notes.RestoreNote(ctx, uint(purged.ID), user); !errors.Is(err, services.ErrNoteNotInTrash) {
+		t.Errorf("nota purgada: error %v, se esperaba note_not_in_trash", err)
+	}
+	if _, err := notes.GetNoteByID(ctx, uint(kept.ID), user); err != nil {
+		t.Errorf("la purga se llevó la nota restaurada: %v", err)
+	}
+}
+
+func TestNoteServiceSearch(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	user := signUp(t, a, "user")
+	other := signUp(t, a, "other")
+	notes := a.Container.NoteService
+
+	_, err := notes.SearchNotes(ctx, &models.NoteSearchQuery{Q: "reunión"}, user)
+	if errors.Is(err, services.ErrSearchUnavailable) {
+		t.Skip("búsqueda deshabilitada; con SQLite compila con -tags sqlite_fts5")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, req := range []*models.CreateNoteRequest{
+		{Title: "Acta de la reunión", Content: "Temas de la reunión <script>alert(1)</script>"},
+		{Title: "Compra", Content: "pan y leche"},
+	} {
+		if _, err := notes.CreateNote(ctx, req, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := notes.CreateNote(ctx, &models.CreateNoteRequest{Title: "Otra reunión", Content: "ajena"}, other); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := notes.SearchNotes(ctx, &models.NoteSearchQuery{Q: "reunión"}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Note.Title != "Acta de la reunión" {
+		t.Fatalf("%d resultados, se esperaba solo el acta", len(hits))
+	}
+
+	// Every engine marks the matches the same way and escapes the rest
+	hit := hits[0]
+	if !strings.Contains(hit.TitleSnippet, "<mark>reunión</mark>") || !strings.Contains(hit.Snippet, "<mark>reunión</mark>") {
+		t.Errorf("fragmentos sin resaltar: %q, %q", hit.TitleSnippet, hit.Snippet)
+	}
+	if strings.Contains(hit.Snippet, "<script>") {
+		t.Errorf("fragmento sin escapar: %q", hit.Snippet)
+	}
+}
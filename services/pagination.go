@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"notasGo/models"
+	"notasGo/repositories"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when the client does not send a limit
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page the server will return
+	MaxPageSize = 100
+)
+
+// cursorPayload is the decoded form of the opaque next_cursor value.
+// It stores the sort key of the last row of the page plus its ID as tie breaker.
+type cursorPayload struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// pageRequest describes how a list query must be sorted and sliced
+type pageRequest struct {
+	sort   string
+	order  string
+	limit  int
+	cursor *cursorPayload
+	offset int
+}
+
+// newPageRequest normalizes the pagination options sent by the client
+func newPageRequest(query models.PageQuery, sort string) (*pageRequest, error) {
+	req := &pageRequest{
+		sort:  sort,
+		order: query.Order,
+		limit: query.Limit,
+	}
+
+	if req.sort == "" {
+		req.sort = "created_at"
+	}
+	if req.order == "" {
+		req.order = "desc"
+	}
+	if req.limit <= 0 {
+		req.limit = DefaultPageSize
+	}
+	if req.limit > MaxPageSize {
+		req.limit = MaxPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil || cursor.Sort != req.sort || cursor.Order != req.order {
+			return nil, ErrInvalidCursor
+		}
+		req.cursor = cursor
+	} else if query.Page > 1 {
+		req.offset = (query.Page - 1) * req.limit
+	}
+
+	return req, nil
+}
+
+// toPage converts the request into the page loaded by the repositories.
+// One extra row is requested so finishPage can tell whether there are more pages.
+func (p *pageRequest) toPage() (repositories.Page, error) {
+	page := repositories.Page{
+		Sort:   p.sort,
+		Order:  p.order,
+		Limit:  p.limit + 1,
+		Offset: p.offset,
+	}
+
+	if p.cursor != nil {
+		value, err := p.cursorValue()
+		if err != nil {
+			return repositories.Page{}, err
+		}
+		page.After = &repositories.PageKey{Value: value, ID: p.cursor.ID}
+	}
+
+	return page, nil
+}
+
+// cursorValue converts the stored sort key back to the column type
+func (p *pageRequest) cursorValue() (interface{}, error) {
+	switch p.sort {
+	case "created_at", "updated_at":
+		t, err := time.Parse(time.RFC3339Nano, p.cursor.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	default:
+		return p.cursor.Value, nil
+	}
+}
+
+// finishPage trims the extra row requested by toPage and builds the page metadata.
+// key returns the sort value and ID of an item, used to build the next cursor.
+func finishPage[T any](p *pageRequest, items []T, key func(T) (string, int64)) ([]T, models.PageInfo) {
+	info := models.PageInfo{Limit: p.limit}
+	if len(items) <= p.limit {
+		return items, info
+	}
+
+	items = items[:p.limit]
+	value, id := key(items[len(items)-1])
+	info.HasMore = true
+	info.NextCursor = encodeCursor(cursorPayload{
+		Sort:  p.sort,
+		Order: p.order,
+		Value: value,
+		ID:    id,
+	})
+	return items, info
+}
+
+// sortKey formats the value of a sortable column for a cursor
+func sortKey(sort string, createdAt, updatedAt time.Time, text string) string {
+	switch sort {
+	case "created_at":
+		return createdAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return updatedAt.Format(time.RFC3339Nano)
+	default:
+		return text
+	}
+}
+
+func encodeCursor(cursor cursorPayload) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*cursorPayload, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor cursorPayload
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"notasGo/i18n"
+	"notasGo/logging"
+	"notasGo/mail"
+	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordResetService struct {
+	users          repositories.UserRepository
+	tokens         repositories.UserTokenRepository
+	mailer         mail.Mailer
+	background     *Background
+	sessionService *SessionService
+	lockoutService *LockoutService
+	publicURL      string
+	ttl            time.Duration
+	bcryptCost     int
+}
+
+// NewPasswordResetService mails the users who forgot their password a token
+// working for ttl to choose a new one, hashed with the given bcrypt cost.
+// The tokens are issued and mailed on background.
+// A reset closes the sessions of the account with sessionService and lifts
+// its login lockout with lockoutService.
+func NewPasswordResetService(users repositories.UserRepository, tokens repositories.UserTokenRepository, mailer mail.Mailer, background *Background, sessionService *SessionService, lockoutService *LockoutService, publicURL string, ttl time.Duration, bcryptCost int) *PasswordResetService {
+	return &PasswordResetService{
+		users:          users,
+		tokens:         tokens,
+		mailer:         mailer,
+		background:     background,
+		sessionService: sessionService,
+		lockoutService: lockoutService,
+		publicURL:      strings.TrimRight(publicURL, "/"),
+		ttl:            ttl,
+		bcryptCost:     bcryptCost,
+	}
+}
+
+// RequestReset mails a reset token to the account of email, unless there
+// is none or it was deactivated. The tokens mailed before stop working.
+// Nothing tells whether an email went out, and the lookup, the token and the
+// email happen in the background, so neither the answer nor its timing
+// reveal which emails are registered.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.RequestReset")
+	defer span.End()
+
+	s.background.Go(ctx, func(ctx context.Context) {
+		if err := s.requestReset(ctx, email); err != nil {
+			logging.FromContext(ctx).Error("No se pudo enviar el email de recuperación de contraseña", slog.Any("error", err))
+		}
+	})
+}
+
+func (s *PasswordResetService) requestReset(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status == "inactivo" {
+		return nil
+	}
+
+	secret, token, err := issueUserToken(ctx, s.tokens, user.ID, models.TokenPurposeResetPassword, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(i18n.DefaultLocale, "mail.reset_password.subject", nil),
+		Body: i18n.Translate(i18n.DefaultLocale, "mail.reset_password.body", i18n.Params{
+			"username": user.Username,
+			"token":    secret,
+			"endpoint": s.publicURL + "/api/v1/auth/reset-password",
+			"expires":  token.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"),
+		}),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("error al enviar el email de recuperación al usuario %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets the password of the account the token was mailed to.
+// A token works once and only until it expires. Every session of the
+// account is closed and its login lockout lifted; a pending account is
+// activated, as the token proves the email belongs to its owner.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.ResetPassword")
+	defer span.End()
+
+	user, err := useUserToken(ctx, s.tokens, s.users, models.TokenPurposeResetPassword, req.Token, ErrInvalidResetToken, func(user *models.User) (map[string]interface{}, error) {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
+		if err != nil {
+			return nil, fmt.Errorf("error al encriptar contraseña: %w", err)
+		}
+
+		updates := map[string]interface{}{"password": string(hashedPassword)}
+		if user.Status == "pendiente" {
+			updates["status"] = "activo"
+		}
+		return updates, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Whoever knew the old password loses access immediately
+	if err := s.sessionService.RevokeUserSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("error al revocar sesiones del usuario: %w", err)
+	}
+	return s.lockoutService.ClearLockout(ctx, user.Email)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notasGo/i18n"
+	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"notasGo/utils"
+	"strconv"
+)
+
+// CurrentRevision refers to the current content of a note in a diff
+const CurrentRevision = "current"
+
+type RevisionService struct {
+	notes       repositories.NoteRepository
+	noteService *NoteService
+}
+
+func NewRevisionService(notes repositories.NoteRepository, noteService *NoteService) *RevisionService {
+	return &RevisionService{
+		notes:       notes,
+		noteService: noteService,
+	}
+}
+
+// RevisionDiff is the unified diff between two versions of a note
+type RevisionDiff struct {
+	From      string
+	To        string
+	FromTitle string
+	ToTitle   string
+	Diff      string
+}
+
+// GetRevisions lists the revisions of a note visible by the caller, newest first
+func (s *RevisionService) GetRevisions(ctx context.Context, noteID uint, caller *models.User) ([]models.NoteRevision, error) {
+	ctx, span := tracing.Start(ctx, "RevisionService.GetRevisions")
+	defer span.End()
+
+	note, err := s.noteService.GetNoteByID(ctx, noteID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.notes.Revisions(ctx, note.ID)
+}
+
+// GetRevision retrieves a single revision of a note visible by the caller
+func (s *RevisionService) GetRevision(ctx context.Context, noteID uint, number int, caller *models.User) (*models.NoteRevision, error) {
+	ctx, span := tracing.Start(ctx, "RevisionService.GetRevision")
+	defer span.End()
+
+	note, err := s.noteService.GetNoteByID(ctx, noteID, caller)
+	if err != nil {
+		return nil, err
+	}
+	return s.findRevision(ctx, note.ID, number)
+}
+
+// DiffRevisions compares two revisions of a note. Either side can be
+// CurrentRevision to compare against the current content. The headers of
+// the diff name both sides in locale. Versions that differ in more than
+// utils.MaxDiffLines lines fail with ErrDiffTooLarge.
+func (s *RevisionService) DiffRevisions(ctx context.Context, noteID uint, from string, to string, caller *models.User, locale string) (*RevisionDiff, error) {
+	ctx, span := tracing.Start(ctx, "RevisionService.DiffRevisions")
+	defer span.End()
+
+	note, err := s.noteService.GetNoteByID(ctx, noteID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	fromTitle, fromContent, err := s.revisionContent(ctx, note, from)
+	if err != nil {
+		return nil, err
+	}
+	toTitle, toContent, err := s.revisionContent(ctx, note, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := utils.UnifiedDiff(revisionLabel(locale, from), revisionLabel(locale, to), fromContent, toContent)
+	if err != nil {
+		if errors.Is(err, utils.ErrDiffTooLarge) {
+			return nil, ErrDiffTooLarge
+		}
+		return nil, err
+	}
+
+	return &RevisionDiff{
+		From:      from,
+		To:        to,
+		FromTitle: fromTitle,
+		ToTitle:   toTitle,
+		Diff:      diff,
+	}, nil
+}
+
+// RestoreRevision brings back the title and content of a revision. The
+// restore is a regular update, so the content it replaces is kept as a new
+// revision and history is never rewritten.
+func (s *RevisionService) RestoreRevision(ctx context.Context, noteID uint, number int, caller *models.User, expectedVersions []int) (*models.Note, error) {
+	ctx, span := tracing.Start(ctx, "RevisionService.RestoreRevision")
+	defer span.End()
+
+	note, err := s.noteService.GetNoteByID(ctx, noteID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := s.findRevision(ctx, note.ID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"title":   revision.Title,
+		"content": revision.Content,
+	}
+	if err := s.noteService.saveNote(ctx, note, updates, nil, caller, expectedVersions); err != nil {
+		return nil, err
+	}
+
+	return s.noteService.GetNoteByID(ctx, noteID, caller)
+}
+
+func (s *RevisionService) findRevision(ctx context.Context, noteID int, number int) (*models.NoteRevision, error) {
+	revision, err := s.notes.FindRevision(ctx, noteID, number)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+// revisionContent resolves a revision number or CurrentRevision to its title and content
+func (s *RevisionService) revisionContent(ctx context.Context, note *models.Note, number string) (string, string, error) {
+	if number == CurrentRevision {
+		return note.Title, note.Content, nil
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return "", "", ErrRevisionNotFound
+	}
+	revision, err := s.findRevision(ctx, note.ID, n)
+	if err != nil {
+		return "", "", err
+	}
+	return revision.Title, revision.Content, nil
+}
+
+// revisionLabel names a side of a diff in locale
+func revisionLabel(locale string, number string) string {
+	if number == CurrentRevision {
+		return i18n.Translate(locale, "revision.current_label", nil)
+	}
+	return i18n.Translate(locale, "revision.label", i18n.Params{"number": number})
+}
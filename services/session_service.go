@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"time"
+)
+
+type SessionService struct {
+	sessions   repositories.SessionRepository
+	refreshTTL time.Duration
+}
+
+// NewSessionService builds a session service whose refresh tokens last refreshTTL
+func NewSessionService(sessions repositories.SessionRepository, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		sessions:   sessions,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// CreateSession opens a new session for the user and returns its refresh token
+func (s *SessionService) CreateSession(ctx context.Context, userID uint) (*models.Session, string, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.CreateSession")
+	defer span.End()
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	session := models.Session{
+		UserID:    userID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+
+	if err := s.sessions.Create(ctx, &session); err != nil {
+		return nil, "", err
+	}
+
+	return &session, refreshToken, nil
+}
+
+// FindSession returns the active session of a refresh token, without
+// spending it, so the caller can check its user before RotateSession
+func (s *SessionService) FindSession(ctx context.Context, refreshToken string) (*models.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.FindSession")
+	defer span.End()
+
+	session, err := s.sessions.FindByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if !session.IsActive() {
+		return nil, ErrInvalidRefreshToken
+	}
+	return session, nil
+}
+
+// RotateSession replaces the refresh token of a session found with
+// FindSession and returns the new one. The old token stops working
+// immediately.
+func (s *SessionService) RotateSession(ctx context.Context, session *models.Session) (string, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.RotateSession")
+	defer span.End()
+
+	newToken, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	// A concurrent refresh of the same token makes the rotation fail
+	if err := s.sessions.Rotate(ctx, session, hashToken(newToken), time.Now().Add(s.refreshTTL)); err != nil {
+		if errors.Is(err, repositories.ErrStaleVersion) {
+			return "", ErrInvalidRefreshToken
+		}
+		return "", err
+	}
+
+	return newToken, nil
+}
+
+// IsSessionActive reports whether the session exists and has not been revoked or expired
+func (s *SessionService) IsSessionActive(ctx context.Context, id uint) (bool, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.IsSessionActive")
+	defer span.End()
+
+	session, err := s.sessions.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.IsActive(), nil
+}
+
+// RevokeUserSessions revokes every active session of the user
+func (s *SessionService) RevokeUserSessions(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "SessionService.RevokeUserSessions")
+	defer span.End()
+
+	return s.sessions.RevokeByUser(ctx, userID, time.Now())
+}
+
+// generateToken returns a random URL-safe token, for refresh tokens and the
+// tokens mailed to users
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error al generar token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 hash of a token, the only form persisted
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"notasGo/repositories"
+)
+
+// Stats are the totals the application exports as business metrics
+type Stats struct {
+	Notes        int64
+	TrashedNotes int64
+	Users        int64
+	ActiveUsers  int64
+}
+
+type StatsService struct {
+	notes repositories.NoteRepository
+	users repositories.UserRepository
+}
+
+func NewStatsService(notes repositories.NoteRepository, users repositories.UserRepository) *StatsService {
+	return &StatsService{
+		notes: notes,
+		users: users,
+	}
+}
+
+// Stats counts the notes and users. Each total is a single COUNT query.
+func (s *StatsService) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	var err error
+
+	if stats.Notes, err = s.notes.Count(ctx, false); err != nil {
+		return stats, err
+	}
+	if stats.TrashedNotes, err = s.notes.Count(ctx, true); err != nil {
+		return stats, err
+	}
+	if stats.Users, err = s.users.Count(ctx, repositories.UserFilter{}); err != nil {
+		return stats, err
+	}
+	if stats.ActiveUsers, err = s.users.Count(ctx, repositories.UserFilter{Status: "activo"}); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+)
+
+type TagService struct {
+	notes repositories.NoteRepository
+}
+
+func NewTagService(notes repositories.NoteRepository) *TagService {
+	return &TagService{
+		notes: notes,
+	}
+}
+
+// GetTags lists the tags used by the notes visible by the caller with their usage counts
+func (s *TagService) GetTags(ctx context.Context, caller *models.User) ([]repositories.TagUsage, error) {
+	ctx, span := tracing.Start(ctx, "TagService.GetTags")
+	defer span.End()
+
+	return s.notes.TagUsage(ctx, visibleOwner(caller))
+}
+
+// parseTagList converts the "tags" value of a PATCH body into tag names
+func parseTagList(value interface{}) ([]string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, ErrInvalidTags
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		name, ok := item.(string)
+		if !ok || len(name) > 50 {
+			return nil, ErrInvalidTags
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
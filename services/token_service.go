@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/rand"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const tokenIssuer = "notasGo"
+
+// AccessClaims are the claims carried by an access token
+type AccessClaims struct {
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+type TokenService struct {
+	secret    []byte
+	accessTTL time.Duration
+}
+
+// NewTokenService builds a token service signing with secret the access
+// tokens that last accessTTL. If secret is empty a random key is generated,
+// so tokens will not survive a restart.
+func NewTokenService(secret string, accessTTL time.Duration) *TokenService {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("No se pudo generar la clave de firma: " + err.Error())
+		}
+		slog.Warn("JWT_SECRET no definido: usando una clave aleatoria, los tokens no sobrevivirán a un reinicio")
+	}
+
+	return &TokenService{
+		secret:    key,
+		accessTTL: accessTTL,
+	}
+}
+
+// AccessTTL returns the lifetime of the access tokens issued by the service
+func (s *TokenService) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// GenerateAccessToken issues a signed HS256 access token for the user session
+func (s *TokenService) GenerateAccessToken(userID uint, role string, sessionID uint) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
+
+	claims := AccessClaims{
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseAccessToken validates the signature and expiry of an access token
+func (s *TokenService) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if _, err := strconv.ParseUint(claims.Subject, 10, 32); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// UserID returns the ID of the user the token was issued to
+func (c *AccessClaims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 32)
+	return uint(id)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"notasGo/logging"
+	"notasGo/models"
+	"notasGo/policy"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"time"
+)
+
+type TrashService struct {
+	notes         repositories.NoteRepository
+	users         repositories.UserRepository
+	trash         repositories.TrashRepository
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+// NewTrashService builds a trash service that keeps deleted items for
+// retention and purges the expired ones every purgeInterval
+func NewTrashService(notes repositories.NoteRepository, users repositories.UserRepository, trash repositories.TrashRepository, retention time.Duration, purgeInterval time.Duration) *TrashService {
+	return &TrashService{
+		notes:         notes,
+		users:         users,
+		trash:         trash,
+		retention:     retention,
+		purgeInterval: purgeInterval,
+	}
+}
+
+// Retention returns how long deleted items stay in the trash before being purged
+func (s *TrashService) Retention() time.Duration {
+	return s.retention
+}
+
+// GetTrash lists the deleted notes visible by the caller, most recent first.
+// Deleted accounts are only listed for admins.
+func (s *TrashService) GetTrash(ctx context.Context, caller *models.User) ([]models.Note, []models.User, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.GetTrash")
+	defer span.End()
+
+	notes, err := s.notes.FindDeletedByOwner(ctx, visibleOwner(caller))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []models.User{}
+	if policy.CanListUsers(caller) {
+		if users, err = s.users.FindDeletedAll(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return notes, users, nil
+}
+
+// Purge permanently removes the notes and users deleted before now minus the
+// retention, together with their revisions, tag links and sessions. Notes of
+// purged users go with them even if they were deleted later.
+func (s *TrashService) Purge(ctx context.Context, now time.Time) (repositories.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.Purge")
+	defer span.End()
+
+	return s.trash.Purge(ctx, now.Add(-s.retention))
+}
+
+// StartPurger runs Purge in the background every purge interval until ctx is
+// done. The returned channel is closed once the purger has stopped, after
+// finishing the purge in progress if any.
+func (s *TrashService) StartPurger(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			// A purge in progress finishes even if ctx is done meanwhile
+			s.runPurge(context.WithoutCancel(ctx))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+// runPurge purges the trash once and logs the outcome
+func (s *TrashService) runPurge(ctx context.Context) {
+	result, err := s.Purge(ctx, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).Error("Error al vaciar la papelera", slog.Any("error", err))
+		return
+	}
+	if result.Notes > 0 || result.Users > 0 {
+		logging.FromContext(ctx).Info("Papelera vaciada",
+			slog.Int64("notes", result.Notes),
+			slog.Int64("users", result.Users))
+	}
+}
@@ -1,62 +1,102 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"notasGo/database"
+	"fmt"
+	"log/slog"
+	"notasGo/logging"
 	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
-type UserService struct{}
+type UserService struct {
+	users               repositories.UserRepository
+	sessionService      *SessionService
+	lockoutService      *LockoutService
+	verificationService *VerificationService
+	bcryptCost          int
 
-func NewUserService() *UserService {
-	return &UserService{}
+	// dummyHash is compared with the password of logins to unknown emails,
+	// so they take as long as the logins to existing accounts
+	dummyHash     []byte
+	dummyHashOnce sync.Once
 }
 
-// GetAllUsers retrieves all users from database
-func (s *UserService) GetAllUsers() ([]models.User, int64, error) {
-	var users []models.User
-	var count int64
-	
-	if err := database.DB.Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
-	
-	database.DB.Model(&models.User{}).Count(&count)
-	return users, count, nil
+// NewUserService builds a user service hashing passwords with the given
+// bcrypt cost, guarding the logins with lockoutService and verifying the
+// email of new accounts with verificationService
+func NewUserService(users repositories.UserRepository, sessionService *SessionService, lockoutService *LockoutService, verificationService *VerificationService, bcryptCost int) *UserService {
+	return &UserService{
+		users:               users,
+		sessionService:      sessionService,
+		lockoutService:      lockoutService,
+		verificationService: verificationService,
+		bcryptCost:          bcryptCost,
+	}
+}
+
+// GetAllUsers retrieves a page of users matching the query filters
+func (s *UserService) GetAllUsers(ctx context.Context, query *models.UserListQuery) ([]models.User, int64, models.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	page, err := newPageRequest(query.PageQuery, query.Sort)
+	if err != nil {
+		return nil, 0, models.PageInfo{}, err
+	}
+	slice, err := page.toPage()
+	if err != nil {
+		return nil, 0, models.PageInfo{}, err
+	}
+
+	filter := repositories.UserFilter{Role: query.Role, Status: query.Status}
+	users, count, err := s.users.List(ctx, filter, slice)
+	if err != nil {
+		return nil, 0, models.PageInfo{}, err
+	}
+
+	users, info := finishPage(page, users, func(user models.User) (string, int64) {
+		return sortKey(page.sort, user.CreatedAt, user.UpdatedAt, user.Username), int64(user.ID)
+	})
+	return users, count, info, nil
 }
 
 // GetUserByID retrieves a user by ID
-func (s *UserService) GetUserByID(id string) (*models.User, error) {
-	var user models.User
-	if err := database.DB.First(&user, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("usuario no encontrado")
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	user, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
 }
 
-// CreateUser creates a new user with hashed password
-func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
-	// Check if email already exists
-	var existingUser models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("el email ya está registrado")
-	}
-	
-	// Check if username already exists
-	if err := database.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		return nil, errors.New("el nombre de usuario ya está en uso")
+// CreateUser creates a new user with hashed password. The account stays
+// pending until the owner of the email follows the link mailed to it.
+func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	// Check if email or username already exist, including accounts in the trash
+	if err := s.checkAvailable(ctx, req.Email, req.Username, 0); err != nil {
+		return nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 	if err != nil {
-		return nil, errors.New("error al encriptar contraseña")
+		return nil, fmt.Errorf("error al encriptar contraseña: %w", err)
 	}
 
 	user := models.User{
@@ -64,39 +104,43 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
 		Email:    req.Email,
 		Password: string(hashedPassword),
 		Role:     "user",
-		Status:   "activo",
+		Status:   "pendiente",
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := s.users.Create(ctx, &user); err != nil {
 		return nil, err
 	}
 
+	s.sendVerification(ctx, &user)
+
 	// Clear password from response
 	user.Password = ""
 	return &user, nil
 }
 
-// UpdateUser updates user information
-func (s *UserService) UpdateUser(id string, req *models.UpdateUserRequest) (*models.User, error) {
-	user, err := s.GetUserByID(id)
+// UpdateUser updates user information. A new email has to be verified
+// again: the account goes back to pending, unless it is inactive or an
+// admin sets the status in the same request, the links mailed to the
+// previous address stop working and a link is mailed to the new one.
+func (s *UserService) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	user, err := s.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check for email conflicts if email is being updated
-	if req.Email != "" && req.Email != user.Email {
-		var existingUser models.User
-		if err := database.DB.Where("email = ? AND id != ?", req.Email, id).First(&existingUser).Error; err == nil {
-			return nil, errors.New("el email ya está en uso por otro usuario")
-		}
+	// Check for conflicts with the email and username being updated
+	email, username := req.Email, req.Username
+	if email == user.Email {
+		email = ""
 	}
-
-	// Check for username conflicts if username is being updated
-	if req.Username != "" && req.Username != user.Username {
-		var existingUser models.User
-		if err := database.DB.Where("username = ? AND id != ?", req.Username, id).First(&existingUser).Error; err == nil {
-			return nil, errors.New("el nombre de usuario ya está en uso")
-		}
+	if username == user.Username {
+		username = ""
+	}
+	if err := s.checkAvailable(ctx, email, username, user.ID); err != nil {
+		return nil, err
 	}
 
 	// Update fields
@@ -112,63 +156,185 @@ func (s *UserService) UpdateUser(id string, req *models.UpdateUserRequest) (*mod
 	}
 	if req.Status != "" {
 		updates["status"] = req.Status
+	} else if email != "" && user.Status != "inactivo" {
+		updates["status"] = "pendiente"
 	}
 
-	if err := database.DB.Model(user).Updates(updates).Error; err != nil {
+	if err := s.users.Update(ctx, user, updates); err != nil {
 		return nil, err
 	}
 
+	if email != "" {
+		if err := s.verificationService.RevokeTokens(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("error al invalidar los tokens del email anterior: %w", err)
+		}
+	}
+
+	// Deactivated accounts lose every open session immediately
+	if req.Status == "inactivo" {
+		if err := s.sessionService.RevokeUserSessions(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("error al revocar sesiones del usuario: %w", err)
+		}
+	}
+
 	// Refresh user data
-	updatedUser, err := s.GetUserByID(id)
+	updatedUser, err := s.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	
+
+	if email != "" && updatedUser.Status == "pendiente" {
+		s.sendVerification(ctx, updatedUser)
+	}
+
 	updatedUser.Password = ""
 	return updatedUser, nil
 }
 
-// DeleteUser deletes a user and all associated notes
-func (s *UserService) DeleteUser(id string) error {
-	user, err := s.GetUserByID(id)
+// DeleteUser moves a user and all associated notes to the trash.
+// Both share the same deletion time so RestoreUser can bring them back together.
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	user, err := s.GetUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	// Delete associated notes first
-	if err := database.DB.Where("user_id = ?", id).Delete(&models.Note{}).Error; err != nil {
-		return errors.New("error al eliminar notas del usuario")
+	// Revoke open sessions so issued tokens stop working immediately
+	if err := s.sessionService.RevokeUserSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("error al revocar sesiones del usuario: %w", err)
 	}
 
-	// Delete user
-	if err := database.DB.Delete(user).Error; err != nil {
-		return errors.New("error al eliminar usuario")
+	if err := s.users.Delete(ctx, user, time.Now()); err != nil {
+		return fmt.Errorf("error al eliminar usuario: %w", err)
 	}
 
 	return nil
 }
 
-// AuthenticateUser validates user credentials
-func (s *UserService) AuthenticateUser(req *models.LoginRequest) (*models.User, error) {
-	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("credenciales inválidas")
+// RestoreUser takes a user out of the trash along with the notes deleted with the account.
+// Notes that were already in the trash before the account was deleted stay there.
+func (s *UserService) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RestoreUser")
+	defer span.End()
+
+	user, err := s.users.FindDeleted(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrUserNotInTrash
 		}
 		return nil, err
 	}
 
-	// Check if user is active
-	if user.Status != "activo" {
-		return nil, errors.New("cuenta inactiva")
+	if err := s.users.Restore(ctx, user); err != nil {
+		return nil, fmt.Errorf("error al restaurar usuario: %w", err)
+	}
+
+	restored, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	restored.Password = ""
+	return restored, nil
+}
+
+// AuthenticateUser validates user credentials.
+// Attempts are refused with a 429 error while the email is rate limited or
+// locked out; unknown emails count failures like existing ones, so the
+// lockout does not reveal which accounts exist. Their password is compared
+// with a dummy hash so they take as long to answer, and the status of the
+// account is only checked once the password matches.
+func (s *UserService) AuthenticateUser(ctx context.Context, req *models.LoginRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AuthenticateUser")
+	defer span.End()
+
+	if err := s.lockoutService.Check(ctx, req.Email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			bcrypt.CompareHashAndPassword(s.dummyPasswordHash(), []byte(req.Password))
+			return nil, s.loginFailed(ctx, req.Email)
+		}
+		return nil, err
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("credenciales inválidas")
+		return nil, s.loginFailed(ctx, req.Email)
+	}
+
+	// Only the owner of the password learns the status of the account
+	switch user.Status {
+	case "activo":
+	case "pendiente":
+		return nil, ErrEmailNotVerified
+	default:
+		return nil, ErrAccountInactive
+	}
+
+	if err := s.lockoutService.RecordSuccess(ctx, req.Email); err != nil {
+		return nil, err
 	}
 
 	// Clear password from response
 	user.Password = ""
-	return &user, nil
-}
\ No newline at end of file
+	return user, nil
+}
+
+// sendVerification mails user a link to verify their email. The account is
+// saved either way and a link that failed to go out can be asked for again,
+// so failures are only logged.
+func (s *UserService) sendVerification(ctx context.Context, user *models.User) {
+	if err := s.verificationService.SendVerification(ctx, user); err != nil {
+		logging.FromContext(ctx).Error("No se pudo enviar el email de verificación", slog.Uint64("user_id", uint64(user.ID)), slog.Any("error", err))
+	}
+}
+
+// dummyPasswordHash returns the hash compared with the password of logins to
+// unknown emails, generated on first use with the configured cost
+func (s *UserService) dummyPasswordHash() []byte {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("notasgo-dummy-password"), s.bcryptCost)
+	})
+	return s.dummyHash
+}
+
+// loginFailed records a failed login for email and returns the error for the client
+func (s *UserService) loginFailed(ctx context.Context, email string) error {
+	if err := s.lockoutService.RecordFailure(ctx, email); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// checkAvailable fails when the email or username, if not empty, belong to an
+// account other than exceptID, including accounts in the trash
+func (s *UserService) checkAvailable(ctx context.Context, email string, username string, exceptID uint) error {
+	if email != "" {
+		taken, err := s.users.EmailTaken(ctx, email, exceptID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrEmailTaken
+		}
+	}
+
+	if username != "" {
+		taken, err := s.users.UsernameTaken(ctx, username, exceptID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrUsernameTaken
+		}
+	}
+
+	return nil
+}
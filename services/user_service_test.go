@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"notasGo/app/apptest"
+	"notasGo/models"
+	"notasGo/services"
+	"testing"
+)
+
+func TestUserServiceEmailsIgnoreCase(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	users := a.Container.UserService
+	signUp(t, a, "ana")
+
+	_, err := users.CreateUser(ctx, &models.CreateUserRequest{Username: "ana2", Email: "ANA@Example.com", Password: apptest.Password})
+	if !errors.Is(err, services.ErrEmailTaken) {
+		t.Errorf("error %v, se esperaba email_taken", err)
+	}
+	_, err = users.CreateUser(ctx, &models.CreateUserRequest{Username: "ana", Email: "otra@example.com", Password: apptest.Password})
+	if !errors.Is(err, services.ErrUsernameTaken) {
+		t.Errorf("error %v, se esperaba username_taken", err)
+	}
+
+	user, err := users.AuthenticateUser(ctx, &models.LoginRequest{Email: "Ana@EXAMPLE.com", Password: apptest.Password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "ana" {
+		t.Errorf("autenticado como %q, se esperaba ana", user.Username)
+	}
+}
+
+func TestUserServiceTrash(t *testing.T) {
+	a := apptest.New(t)
+	ctx := context.Background()
+	users := a.Container.UserService
+	user := signUp(t, a, "user")
+	if _, err := a.Container.NoteService.CreateNote(ctx, &models.CreateNoteRequest{Title: "Nota", Content: "nota"}, user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetUserByID(ctx, user.ID); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("usuario borrado visible: error %v", err)
+	}
+	if _, err := users.AuthenticateUser(ctx, &models.LoginRequest{Email: user.Email, Password: apptest.Password}); err == nil {
+		t.Error("un usuario borrado pudo iniciar sesión")
+	}
+
+	if _, err := users.RestoreUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	notes, _, _, err := a.Container.NoteService.GetAllNotes(ctx, user, &models.NoteListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 {
+		t.Errorf("%d notas tras restaurar la cuenta, se esperaba 1", len(notes))
+	}
+}
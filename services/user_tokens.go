@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notasGo/models"
+	"notasGo/repositories"
+	"time"
+)
+
+// issueUserToken stores a new token with the given purpose for the user,
+// working for ttl, and returns it along with the secret to mail. The tokens
+// issued before for the same purpose stop working.
+func issueUserToken(ctx context.Context, tokens repositories.UserTokenRepository, userID uint, purpose string, ttl time.Duration) (string, *models.UserToken, error) {
+	now := time.Now()
+
+	// Expired tokens are of no use to anyone
+	if err := tokens.DeleteExpired(ctx, now); err != nil {
+		return "", nil, err
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(secret),
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := tokens.Replace(ctx, &token); err != nil {
+		return "", nil, err
+	}
+	return secret, &token, nil
+}
+
+// useUserToken spends the token with the given purpose and secret, applying
+// the updates returned by apply to the user it was mailed to in the same
+// transaction. Unknown, used and expired tokens, as well as tokens of users
+// in the trash, return invalid.
+func useUserToken(ctx context.Context, tokens repositories.UserTokenRepository, users repositories.UserRepository, purpose string, secret string, invalid error, apply func(user *models.User) (map[string]interface{}, error)) (*models.User, error) {
+	token, err := tokens.FindByHash(ctx, purpose, hashToken(secret))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !token.IsUsable(now) {
+		return nil, invalid
+	}
+
+	user, err := users.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	updates, err := apply(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// A concurrent request with the same token makes this one fail
+	if err := tokens.Use(ctx, token, now, updates); err != nil {
+		if errors.Is(err, repositories.ErrStaleVersion) {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	// Refresh user data
+	updated, err := users.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Password = ""
+	return updated, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"notasGo/i18n"
+	"notasGo/logging"
+	"notasGo/mail"
+	"notasGo/models"
+	"notasGo/repositories"
+	"notasGo/tracing"
+	"strings"
+	"time"
+)
+
+type VerificationService struct {
+	users      repositories.UserRepository
+	tokens     repositories.UserTokenRepository
+	mailer     mail.Mailer
+	background *Background
+	publicURL  string
+	ttl        time.Duration
+}
+
+// NewVerificationService mails the new accounts a link to publicURL that
+// verifies their email, working for ttl. The links asked for again are
+// mailed on background.
+func NewVerificationService(users repositories.UserRepository, tokens repositories.UserTokenRepository, mailer mail.Mailer, background *Background, publicURL string, ttl time.Duration) *VerificationService {
+	return &VerificationService{
+		users:      users,
+		tokens:     tokens,
+		mailer:     mailer,
+		background: background,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		ttl:        ttl,
+	}
+}
+
+// SendVerification mails user a new link to verify their email. The links
+// mailed before stop working.
+func (s *VerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "VerificationService.SendVerification")
+	defer span.End()
+
+	secret, token, err := issueUserToken(ctx, s.tokens, user.ID, models.TokenPurposeVerifyEmail, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(i18n.DefaultLocale, "mail.verify_email.subject", nil),
+		Body: i18n.Translate(i18n.DefaultLocale, "mail.verify_email.body", i18n.Params{
+			"username": user.Username,
+			"link":     s.publicURL + "/api/v1/auth/verify?token=" + secret,
+			"expires":  token.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"),
+		}),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("error al enviar el email de verificación: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification mails a new link to the account of email if it is
+// still pending verification. Nothing tells whether it is, and the lookup
+// and the email happen in the background, so neither the answer nor its
+// timing reveal which emails are registered.
+func (s *VerificationService) ResendVerification(ctx context.Context, email string) {
+	ctx, span := tracing.Start(ctx, "VerificationService.ResendVerification")
+	defer span.End()
+
+	s.background.Go(ctx, func(ctx context.Context) {
+		if err := s.resendVerification(ctx, email); err != nil {
+			logging.FromContext(ctx).Error("No se pudo reenviar el email de verificación", slog.Any("error", err))
+		}
+	})
+}
+
+func (s *VerificationService) resendVerification(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status != "pendiente" {
+		return nil
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// RevokeTokens invalidates every token mailed to user, whatever its
+// purpose. It is called when the email changes, so the links mailed to the
+// previous address stop working.
+func (s *VerificationService) RevokeTokens(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "VerificationService.RevokeTokens")
+	defer span.End()
+
+	return s.tokens.DeleteByUser(ctx, userID)
+}
+
+// VerifyEmail activates the pending account the token was mailed to. A
+// token works once and only until it expires; accounts deactivated in the
+// meantime stay inactive.
+func (s *VerificationService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "VerificationService.VerifyEmail")
+	defer span.End()
+
+	return useUserToken(ctx, s.tokens, s.users, models.TokenPurposeVerifyEmail, token, ErrInvalidVerificationToken, func(user *models.User) (map[string]interface{}, error) {
+		if user.Status != "pendiente" {
+			return nil, nil
+		}
+		return map[string]interface{}{"status": "activo"}, nil
+	})
+}
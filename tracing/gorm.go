@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey stores the span of an operation in its gorm statement
+const spanKey = "tracing:span"
+
+// callbackPrefix names the callbacks registered by the plugin
+const callbackPrefix = "tracing:"
+
+// rowsAffectedKey records the rows returned or changed by an operation
+const rowsAffectedKey = attribute.Key("db.rows_affected")
+
+// systems maps the gorm dialector names to the db.system.name values
+var systems = map[string]attribute.KeyValue{
+	"sqlite":   semconv.DBSystemNameSQLite,
+	"postgres": semconv.DBSystemNamePostgreSQL,
+	"mysql":    semconv.DBSystemNameMySQL,
+}
+
+// GORMPlugin returns a gorm plugin that records a client span for every
+// operation run on the database it is used on, child of the span carried by
+// the context of the query:
+//
+//	db.Use(tracing.GORMPlugin())
+//
+// The spans hold the SQL with placeholders, never the parameters.
+func GORMPlugin() gorm.Plugin {
+	return &gormPlugin{}
+}
+
+type gormPlugin struct{}
+
+func (p *gormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize wraps each kind of operation with a callback before and after
+// the gorm ones
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("*").Register, callbacks.Create().After("*").Register},
+		{"query", callbacks.Query().Before("*").Register, callbacks.Query().After("*").Register},
+		{"update", callbacks.Update().Before("*").Register, callbacks.Update().After("*").Register},
+		{"delete", callbacks.Delete().Before("*").Register, callbacks.Delete().After("*").Register},
+		{"row", callbacks.Row().Before("*").Register, callbacks.Row().After("*").Register},
+		{"raw", callbacks.Raw().Before("*").Register, callbacks.Raw().After("*").Register},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before(callbackPrefix+"before_"+processor.operation, startSpan(processor.operation)); err != nil {
+			return err
+		}
+		if err := processor.after(callbackPrefix+"after_"+processor.operation, endSpan(processor.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSpan returns the callback starting the span of an operation. The
+// table is not known yet, so the span is renamed once gorm ran it.
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := tracer.Start(db.Statement.Context, operation, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// endSpan returns the callback describing and ending the span of an operation
+func endSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+
+		attrs := []attribute.KeyValue{
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			rowsAffectedKey.Int64(db.RowsAffected),
+		}
+		if system, ok := systems[db.Dialector.Name()]; ok {
+			attrs = append(attrs, system)
+		}
+		if table := db.Statement.Table; table != "" {
+			span.SetName(operation + " " + table)
+			attrs = append(attrs, semconv.DBCollectionName(table))
+		}
+		span.SetAttributes(attrs...)
+
+		// A lookup that finds nothing is an expected outcome, not a failure
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+	}
+}
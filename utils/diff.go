@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// MaxDiffLines is how many lines each text may have between their common
+// beginning and end. The comparison takes memory proportional to the
+// product of both counts, so larger changes are refused.
+const MaxDiffLines = 2000
+
+// ErrDiffTooLarge is returned by UnifiedDiff when the changed part of a text
+// is longer than MaxDiffLines
+var ErrDiffTooLarge = errors.New("diff demasiado grande")
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns the line based unified diff between two texts, with
+// three lines of context around each change. It returns an empty string when
+// both texts are equal, and ErrDiffTooLarge when they differ in too many
+// lines to compare.
+func UnifiedDiff(fromName, toName, from, to string) (string, error) {
+	ops, err := diffLines(splitLines(from), splitLines(to))
+	if err != nil {
+		return "", err
+	}
+
+	var hunks strings.Builder
+	for start := 0; start < len(ops); {
+		// Skip unchanged lines until the next change
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// Extend the hunk while changes are closer than two context windows
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i
+			} else if i-end > 2*diffContext {
+				break
+			}
+		}
+
+		hunkStart := max(start-diffContext, 0)
+		hunkEnd := min(end+diffContext+1, len(ops))
+		writeHunk(&hunks, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	if hunks.Len() == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", fromName, toName, hunks.String()), nil
+}
+
+// writeHunk writes the ops in [start, end) with its @@ header
+func writeHunk(b *strings.Builder, ops []diffOp, start, end int) {
+	fromLine, toLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			fromLine++
+		}
+		if op.kind != '-' {
+			toLine++
+		}
+	}
+
+	fromCount, toCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+	}
+
+	// Empty ranges point at the line before the hunk, as in GNU diff
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+	for _, op := range ops[start:end] {
+		b.WriteByte(op.kind)
+		b.WriteString(op.line)
+		b.WriteByte('\n')
+	}
+}
+
+// diffLines computes the edit script between two line slices using the
+// longest common subsequence of the lines between their common prefix and
+// suffix
+func diffLines(a, b []string) ([]diffOp, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	changedA, changedB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(changedA), len(changedB)
+	if n > MaxDiffLines || m > MaxDiffLines {
+		return nil, ErrDiffTooLarge
+	}
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if changedA[i] == changedB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case changedA[i] == changedB[j]:
+			ops = append(ops, diffOp{' ', changedA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', changedA[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', changedB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', changedA[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', changedB[j]})
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops, nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -1,52 +1,35 @@
 package utils
 
 import (
-	"net/http"
+	"notasGo/i18n"
 	"notasGo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Success responses
-func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
+// Success responses. messageID is translated to the locale of the request
+func SuccessResponse(c *gin.Context, statusCode int, messageID string, data interface{}) {
 	c.JSON(statusCode, models.APIResponse{
 		Success: true,
-		Message: message,
+		Message: i18n.T(c, messageID),
 		Data:    data,
 	})
 }
 
-// Error responses
-func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
+// Error responses. code is a stable identifier for clients, messageID is
+// translated to the locale of the request, fields lists the rejected request
+// fields and err adds details
+func ErrorResponse(c *gin.Context, statusCode int, code string, messageID string, fields []models.FieldError, err error) {
 	response := models.ErrorResponse{
 		Success: false,
-		Message: message,
+		Code:    code,
+		Message: i18n.T(c, messageID),
+		Fields:  fields,
 	}
-	
+
 	if err != nil {
 		response.Error = err.Error()
 	}
-	
-	c.JSON(statusCode, response)
-}
 
-// Common error responses
-func BadRequestError(c *gin.Context, message string, err error) {
-	ErrorResponse(c, http.StatusBadRequest, message, err)
-}
-
-func NotFoundError(c *gin.Context, message string) {
-	ErrorResponse(c, http.StatusNotFound, message, nil)
-}
-
-func InternalServerError(c *gin.Context, message string, err error) {
-	ErrorResponse(c, http.StatusInternalServerError, message, err)
-}
-
-func UnauthorizedError(c *gin.Context, message string) {
-	ErrorResponse(c, http.StatusUnauthorized, message, nil)
+	c.JSON(statusCode, response)
 }
-
-func ConflictError(c *gin.Context, message string, err error) {
-	ErrorResponse(c, http.StatusConflict, message, err)
-}
\ No newline at end of file
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"notasGo/i18n"
+	"notasGo/models"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterFieldNames makes the binding validator report fields by the name
+// clients send (json tag, or form tag for query strings) instead of the Go
+// struct field name
+func RegisterFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// ValidationFields converts binding errors into field level details with
+// messages in the given locale. It returns nil when err is not a validation
+// or JSON type error.
+func ValidationFields(err error, locale string) []models.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]models.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, models.FieldError{
+				Field: fieldErr.Field(),
+				Rule:  fieldErr.Tag(),
+				Param: fieldErr.Param(),
+				Message: i18n.Translate(locale, validationMessage(fieldErr), i18n.Params{
+					"field": fieldErr.Field(),
+					"param": strings.ReplaceAll(fieldErr.Param(), " ", ", "),
+				}),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		typeName := jsonTypeName(typeErr.Type)
+		return []models.FieldError{{
+			Field: typeErr.Field,
+			Rule:  "type",
+			Param: typeName,
+			Message: i18n.Translate(locale, "validation.type", i18n.Params{
+				"field": typeErr.Field,
+				"param": i18n.Translate(locale, "type."+typeName, nil),
+			}),
+		}}
+	}
+
+	return nil
+}
+
+// validationMessage returns the message ID describing a failed validation rule
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required", "email", "oneof":
+		return "validation." + fieldErr.Tag()
+	case "min", "max":
+		switch fieldErr.Kind() {
+		case reflect.String:
+			if fieldErr.Tag() == "min" && fieldErr.Param() == "1" {
+				return "validation.not_empty"
+			}
+			return "validation." + fieldErr.Tag() + "_length"
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return "validation." + fieldErr.Tag() + "_items"
+		default:
+			return "validation." + fieldErr.Tag() + "_value"
+		}
+	default:
+		return "validation.invalid"
+	}
+}
+
+// jsonTypeName describes a Go type with the JSON vocabulary clients know
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}